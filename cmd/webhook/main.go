@@ -17,17 +17,16 @@ package main
 
 import (
 	"context"
-	"crypto/sha512"
 	"crypto/tls"
-	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -37,12 +36,16 @@ import (
 	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/webhook"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/client-go/tools/cache"
 	cliflag "k8s.io/component-base/cli/flag"
 )
 
 const (
 	metricsPath = "/metrics"
 	healthzPath = "/healthz"
+	livezPath   = "/livez"
+	readyzPath  = "/readyz"
 )
 
 // ServerConfig holds configuration for the HTTP servers
@@ -54,6 +57,24 @@ type ServerConfig struct {
 	TLSMinVersion   string
 	TLSCipherSuites StringSliceFlag
 	GetCertificate  func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	// ClientAuth verifies the client certificates of the webhook listener if set
+	ClientAuth *webhook.ClientAuth
+	// MetricsAuth authenticates and authorizes the metrics requests if set
+	MetricsAuth *webhook.DelegatedAuth
+	// LivenessChecks and ReadinessChecks are the checks of /livez and /readyz,
+	// in addition to the webhook listener check
+	LivenessChecks  []webhook.HealthCheck
+	ReadinessChecks []webhook.HealthCheck
+
+	MaxRequestBodyBytes int64
+	RequestTimeout      time.Duration
+	InFlightLimits      webhook.InFlightLimits
+	ReadHeaderTimeout   time.Duration
+	ReadTimeout         time.Duration
+	WriteTimeout        time.Duration
+	IdleTimeout         time.Duration
+	ShutdownDelay       time.Duration
+	ShutdownTimeout     time.Duration
 }
 
 // StringSliceFlag implements flag.Value interface for comma-separated string lists
@@ -79,86 +100,376 @@ func (s *StringSliceFlag) Set(value string) error {
 }
 
 func main() {
+	// "webhook simulate [flags]" reports what the checks would deny instead of serving
+	args := os.Args[1:]
+	simulate := len(args) > 0 && args[0] == "simulate"
+	if simulate {
+		args = args[1:]
+	}
+
 	// load configuration
-	cert := flag.String("tls-cert-file", "cert.pem", "File containing the default x509 Certificate for HTTPS.")
-	key := flag.String("tls-private-key-file", "key.pem", "File containing the default x509 private key matching --tls-cert-file.")
+	certFiles := StringSliceFlag{"cert.pem"}
+	flag.Var(&certFiles, "tls-cert-file", "Comma separated files containing the x509 Certificates for HTTPS, e.g. an RSA and an ECDSA one, each client is served the first one it supports.")
+	keyFiles := StringSliceFlag{"key.pem"}
+	flag.Var(&keyFiles, "tls-private-key-file", "Comma separated files containing the x509 private keys matching --tls-cert-file.")
+	tlsSecret := flag.String("tls-secret", "", "Secret (namespace/name) holding the x509 key pair for HTTPS, watched instead of --tls-cert-file and --tls-private-key-file")
+	generateCerts := flag.Bool("generate-certs", false, "Generate a self-signed CA and serving certificate into the --tls-secret secret, rotate them before they expire and set the caBundle of the webhook configurations calling --webhook-service")
+	webhookService := flag.String("webhook-service", "net-attach-def-admission-controller-service", "Service (name or namespace/name, in the namespace of --tls-secret or of the pod by default) the generated serving certificate is valid for and the registered webhooks call")
+	certRotation := &webhook.CertRotationConfig{}
+	flag.DurationVar(&certRotation.CAValidity, "ca-validity", webhook.DefaultCAValidity, "Lifetime of the CA generated with --generate-certs")
+	flag.DurationVar(&certRotation.CertValidity, "cert-validity", webhook.DefaultCertValidity, "Lifetime of the serving certificate generated with --generate-certs")
+	flag.DurationVar(&certRotation.RotateBefore, "cert-rotate-before", webhook.DefaultCertRotateBefore, "How long before they expire the generated CA and serving certificate are replaced")
+	var certExpiryWarningThresholds StringSliceFlag
+	for _, threshold := range webhook.DefaultCertExpiryWarningThresholds {
+		certExpiryWarningThresholds = append(certExpiryWarningThresholds, threshold.String())
+	}
+	flag.Var(&certExpiryWarningThresholds, "cert-expiry-warning-thresholds", "Comma separated durations before the serving certificate expires at which a warning is logged")
+
+	var registerWebhooks StringSliceFlag
+	flag.Var(&registerWebhooks, "register-webhooks", "Comma separated webhooks (validate, isolate) whose configurations are created or updated at startup to call --webhook-service")
+	webhookOwner := flag.String("webhook-owner", "net-attach-def-admission-controller-server", "Deployment, in the namespace of --webhook-service, recorded as the owner of the registered webhook configurations")
+	failurePolicy := flag.String("failure-policy", string(admissionregistrationv1.Fail), "Failure policy of the registered webhooks, Fail or Ignore")
+	webhookTimeoutSeconds := flag.Int("webhook-timeout-seconds", webhook.DefaultWebhookTimeoutSeconds, "timeoutSeconds of the registered webhooks")
+	webhookCABundleFile := flag.String("webhook-ca-bundle-file", "", "File containing the caBundle of the registered webhooks, the current caBundle is kept if not set")
+	webhookShutdownAction := flag.String("webhook-shutdown-action", string(webhook.WebhookShutdownKeep), "Action on the registered webhook configurations on a clean shutdown, keep, disable (set the failure policy to Ignore) or delete")
 
 	config := &ServerConfig{}
 	flag.IntVar(&config.Port, "port", 443, "The port on which to serve.")
 	flag.StringVar(&config.Address, "bind-address", "0.0.0.0", "The IP address on which to listen for the --port port.")
 	flag.StringVar(&config.MetricsAddress, "metrics-listen-address", ":9091", "metrics server listen address.")
 	flag.BoolVar(&config.EncryptMetrics, "encrypt-metrics", false, "serve metrics over HTTPS using tls-cert-file/tls-private-key-file x509 key pair")
+	metricsAuth := flag.Bool("metrics-auth", false, "Authenticate the bearer tokens of metrics requests with TokenReviews and authorize them with SubjectAccessReviews on the /metrics non-resource URL, requires --encrypt-metrics")
+	delegatedAuth := webhook.DelegatedAuth{}
+	flag.DurationVar(&delegatedAuth.AllowedTTL, "metrics-auth-allowed-ttl", webhook.DefaultAuthAllowedTTL, "How long authenticated tokens and allowed decisions of --metrics-auth are cached")
+	flag.DurationVar(&delegatedAuth.DeniedTTL, "metrics-auth-denied-ttl", webhook.DefaultAuthDeniedTTL, "How long rejected tokens and denied decisions of --metrics-auth are cached")
 	flag.StringVar(&config.TLSMinVersion, "tls-min-version", "", "Minimum TLS version supported")
 	flag.Var(&config.TLSCipherSuites, "tls-cipher-suites", "Comma-separated list of cipher suites")
+	clientCAFile := flag.String("client-ca-file", "", "File containing the CA bundle verifying the client certificates of webhook requests, reloaded on change. Requests without a valid client certificate are rejected if set")
+	var clientAllowedSubjects StringSliceFlag
+	flag.Var(&clientAllowedSubjects, "client-allowed-subjects", "Comma separated common names of the client certificates allowed with --client-ca-file (default: any client certificate signed by the CA)")
+	flag.Int64Var(&config.MaxRequestBodyBytes, "max-request-body-bytes", webhook.DefaultMaxRequestBodyBytes, "Largest AdmissionReview request body the webhook accepts (0 for no limit)")
+	flag.DurationVar(&config.RequestTimeout, "request-timeout", webhook.DefaultRequestTimeout, "Deadline for handling a single admission request (0 for no deadline)")
+	flag.IntVar(&config.InFlightLimits.MaxInFlight, "max-in-flight-requests", 0, "Largest number of admission requests handled concurrently (0 for no limit)")
+	flag.IntVar(&config.InFlightLimits.MaxQueued, "max-queued-requests", 0, "Largest number of admission requests waiting when -max-in-flight-requests are handled")
+	flag.DurationVar(&config.InFlightLimits.QueueTimeout, "queue-timeout", webhook.DefaultQueueTimeout, "Longest time an admission request waits in the queue")
+	overloadBehavior := flag.String("overload-behavior", string(webhook.OverloadReject), "Answer to admission requests that do not fit in the queue, reject (429 Too Many Requests) or allow (admit with a warning)")
+	flag.DurationVar(&config.ReadHeaderTimeout, "read-header-timeout", 10*time.Second, "Maximum duration for reading request headers")
+	flag.DurationVar(&config.ReadTimeout, "read-timeout", 30*time.Second, "Maximum duration for reading an entire request, including the body")
+	flag.DurationVar(&config.WriteTimeout, "write-timeout", 30*time.Second, "Maximum duration before timing out writes of a response")
+	flag.DurationVar(&config.IdleTimeout, "idle-timeout", 120*time.Second, "Maximum duration to wait for the next request on a keep-alive connection")
+	flag.DurationVar(&config.ShutdownDelay, "shutdown-delay", 5*time.Second, "How long the webhook keeps serving after a termination signal fails /readyz, so that it is removed from the service endpoints")
+	flag.DurationVar(&config.ShutdownTimeout, "shutdown-timeout", 15*time.Second, "How long in-flight requests are given to complete once the webhook stops accepting connections")
+
+	rulesFile := flag.String("rules-file", "", "File containing CEL validation rules for net-attach-defs and pods, reloaded on change")
+	deprecationsFile := flag.String("deprecations-file", "", "File containing the catalog of deprecated cniVersions, plugins and annotation formats to warn about, reloaded on change")
 
 	var ignoreNamespaces StringSliceFlag
 	flag.Var(&ignoreNamespaces, "ignore-namespaces", "Comma separated namespace list to ignore pod update")
 
-	flag.Parse()
+	var defaultNetworkNamespaces StringSliceFlag
+	flag.Var(&defaultNetworkNamespaces, "default-network-namespaces", "Comma separated namespace list allowed to override the default network (default: all namespaces)")
 
-	glog.Infof("starting net-attach-def-admission-controller webhook server")
+	enablePolicies := flag.Bool("enable-network-attachment-policies", false, "Enforce NetworkAttachmentPolicy objects on net-attach-def validation")
+
+	tuningRestrictions := webhook.TuningRestrictions{}
+	allowedSysctls := StringSliceFlag(webhook.DefaultAllowedSysctls)
+	deniedSysctls := StringSliceFlag(webhook.DefaultDeniedSysctls)
+	flag.BoolVar(&tuningRestrictions.Enabled, "restrict-tuning", false, "Deny net-attach-defs setting disallowed sysctls, promisc, allmulti or an MTU beyond the limit")
+	flag.Var(&allowedSysctls, "tuning-allowed-sysctls", "Comma separated sysctl names or glob patterns the tuning plugin may set")
+	flag.Var(&deniedSysctls, "tuning-denied-sysctls", "Comma separated sysctl names or glob patterns the tuning plugin must not set")
+	flag.IntVar(&tuningRestrictions.MaxMTU, "max-mtu", webhook.DefaultMaxMTU, "Largest MTU a net-attach-def may set, unless a policy permits more (0 for no limit)")
+
+	quotas := webhook.Quotas{}
+	flag.IntVar(&quotas.MaxNetworksPerPod, "max-networks-per-pod", 0, "Largest number of networks a pod may request, namespaces may override it with the k8s.v1.cni.cncf.io/max-networks-per-pod annotation (0 for no limit)")
+	flag.IntVar(&quotas.MaxNetAttachDefsPerNamespace, "max-net-attach-defs-per-namespace", 0, "Largest number of net-attach-defs per namespace, namespaces may override it with the k8s.v1.cni.cncf.io/max-net-attach-defs annotation (0 for no limit)")
+
+	enforcementMode := flag.String("enforcement-mode", string(webhook.EnforcementModeEnforce), "Enforcement mode of failed checks, enforce, warn or audit, optionally followed by check=mode pairs (e.g. warn,quota=enforce), namespaces may override it with the k8s.v1.cni.cncf.io/enforcement-mode annotation")
 
-	keyPair, err := webhook.NewTLSKeypairReloader(*cert, *key)
+	simulateDir := flag.String("simulate-from-dir", "", "simulate: read the objects from the YAML and JSON files in this directory instead of the API server")
+	simulateOutput := flag.String("simulate-output", "table", "simulate: report format, one of table, json, junit")
+
+	flag.CommandLine.Parse(args)
+
+	enforcementModes, err := webhook.ParseEnforcementModes(*enforcementMode)
 	if err != nil {
-		glog.Fatalf("error load certificate: %s", err.Error())
+		glog.Fatalf("invalid -enforcement-mode: %v", err)
 	}
-	config.GetCertificate = keyPair.GetCertificateFunc()
-
-	proc, err := os.FindProcess(os.Getpid())
+	config.InFlightLimits.Behavior, err = webhook.ParseOverloadBehavior(*overloadBehavior)
 	if err != nil {
-		glog.Fatalf("error to get process info: %s", err.Error())
+		glog.Fatalf("invalid -overload-behavior: %v", err)
 	}
 
-	// Register metrics
-	prometheus.MustRegister(localmetrics.NetAttachDefInstanceCounter)
-	prometheus.MustRegister(localmetrics.NetAttachDefEnabledInstanceUp)
+	webhook.SetDefaultNetworkNamespaces(defaultNetworkNamespaces)
+	tuningRestrictions.AllowedSysctls = allowedSysctls
+	tuningRestrictions.DeniedSysctls = deniedSysctls
+	webhook.SetTuningRestrictions(tuningRestrictions)
+	webhook.SetQuotas(quotas)
+	webhook.SetEnforcementModes(enforcementModes)
 
-	// Including these stats kills performance when Prometheus polls with multiple targets
-	prometheus.Unregister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
-	prometheus.Unregister(prometheus.NewGoCollector())
+	var thresholds []time.Duration
+	for _, value := range certExpiryWarningThresholds {
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			glog.Fatalf("invalid -cert-expiry-warning-thresholds: %v", err)
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	webhook.SetCertExpiryWarningThresholds(thresholds)
+
+	if *metricsAuth {
+		// bearer tokens must not be sent in clear text
+		if !config.EncryptMetrics {
+			glog.Fatalf("-metrics-auth requires -encrypt-metrics")
+		}
+		config.MetricsAuth = &delegatedAuth
+	}
+
+	if simulate {
+		if err := runSimulation(os.Stdout, *simulateDir, *simulateOutput, *rulesFile, *enablePolicies); err != nil {
+			glog.Fatalf("error running simulation: %v", err)
+		}
+		return
+	}
+
+	glog.Infof("starting net-attach-def-admission-controller webhook server")
+
+	// the root context is canceled on the first signal, a second signal
+	// terminates the webhook at once
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	// the informers, reloaders and watchers keep running while the in-flight
+	// requests are drained, they are stopped once serve returns
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	stopCh := workersCtx.Done()
 
 	// init API client
 	webhook.SetupInClusterClient()
 
-	// Start HTTP servers (metrics and webhook)
-	cleanup, err := startHTTPServers(config)
+	// the service defaults to the namespace of the secret, then of the pod
+	serviceNamespace, serviceName, err := cache.SplitMetaNamespaceKey(*webhookService)
 	if err != nil {
-		glog.Fatalf("error starting HTTP servers: %v", err)
+		glog.Fatalf("invalid -webhook-service: %v", err)
+	}
+	if serviceNamespace == "" && *tlsSecret != "" {
+		serviceNamespace, _, _ = cache.SplitMetaNamespaceKey(*tlsSecret)
+	}
+	if serviceNamespace == "" {
+		serviceNamespace = podNamespace()
 	}
-	defer cleanup()
 
-	// Start watching for pod creations
-	go controller.StartWatching(ignoreNamespaces)
+	var registration *webhook.WebhookRegistration
+	if len(registerWebhooks) > 0 {
+		if serviceNamespace == "" {
+			glog.Fatalf("-register-webhooks requires -webhook-service=namespace/name")
+		}
+		registration = &webhook.WebhookRegistration{
+			Webhooks:         registerWebhooks,
+			ServiceNamespace: serviceNamespace,
+			ServiceName:      serviceName,
+			Owner:            *webhookOwner,
+			TimeoutSeconds:   int32(*webhookTimeoutSeconds),
+			IgnoreNamespaces: ignoreNamespaces,
+		}
+		if registration.FailurePolicy, err = webhook.ParseFailurePolicy(*failurePolicy); err != nil {
+			glog.Fatalf("invalid -failure-policy: %v", err)
+		}
+		if registration.ShutdownAction, err = webhook.ParseWebhookShutdownAction(*webhookShutdownAction); err != nil {
+			glog.Fatalf("invalid -webhook-shutdown-action: %v", err)
+		}
+		if *webhookCABundleFile != "" {
+			if registration.CABundle, err = os.ReadFile(*webhookCABundleFile); err != nil {
+				glog.Fatalf("error reading -webhook-ca-bundle-file: %v", err)
+			}
+		}
+		if err := webhook.RegisterWebhooks(ctx, registration); err != nil {
+			glog.Fatalf("error registering webhooks: %v", err)
+		}
+	}
+
+	if *generateCerts {
+		if *tlsSecret == "" {
+			glog.Fatalf("-generate-certs requires -tls-secret")
+		}
+		certRotation.SecretNamespace, certRotation.SecretName, err = cache.SplitMetaNamespaceKey(*tlsSecret)
+		if err != nil || certRotation.SecretNamespace == "" {
+			glog.Fatalf("invalid -tls-secret %q, must be namespace/name", *tlsSecret)
+		}
+		certRotation.ServiceNamespace, certRotation.ServiceName = serviceNamespace, serviceName
+		if err := certRotation.Validate(); err != nil {
+			glog.Fatalf("invalid certificate rotation flags: %v", err)
+		}
+		if err := webhook.StartCertRotation(certRotation, stopCh); err != nil {
+			glog.Fatalf("error generating certificates: %v", err)
+		}
+	}
 
-	// watch the cert file and restart http sever if the file updated.
-	oldHashVal := ""
-	for {
-		hasher := sha512.New()
-		certPath, err := filepath.Abs(*cert)
+	if *tlsSecret != "" {
+		keyPair, err := webhook.NewTLSSecretReloader(*tlsSecret, stopCh)
+		if err != nil {
+			glog.Fatalf("error load certificate from secret: %s", err.Error())
+		}
+		config.GetCertificate = keyPair.GetCertificateFunc()
+	} else {
+		keyPair, err := webhook.NewTLSKeypairReloaders(certFiles, keyFiles, stopCh)
 		if err != nil {
-			glog.Fatalf("illegal path %s in certPath: %s: %v", *cert, certPath, err)
-			os.Exit(1)
+			glog.Fatalf("error load certificate: %s", err.Error())
 		}
-		s, err := ioutil.ReadFile(certPath)
-		hasher.Write(s)
+		config.GetCertificate = keyPair.GetCertificateFunc()
+	}
+	webhook.StartCertificateExpiryMonitor(stopCh)
+
+	if *clientCAFile != "" {
+		config.ClientAuth, err = webhook.NewClientAuth(*clientCAFile, clientAllowedSubjects, stopCh)
 		if err != nil {
-			glog.Fatalf("failed to read file %s: %v", *cert, err)
-			os.Exit(1)
+			glog.Fatalf("error loading client CA bundle: %v", err)
+		}
+	} else if len(clientAllowedSubjects) > 0 {
+		glog.Fatalf("-client-allowed-subjects requires -client-ca-file")
+	}
+
+	// Register metrics
+	prometheus.MustRegister(localmetrics.NetAttachDefInstanceCounter)
+	prometheus.MustRegister(localmetrics.NetAttachDefEnabledInstanceUp)
+	prometheus.MustRegister(localmetrics.NetAttachDefAttachments)
+	prometheus.MustRegister(localmetrics.AdmissionCheckFailures)
+	prometheus.MustRegister(localmetrics.DeprecatedFeatureUses)
+	prometheus.MustRegister(localmetrics.AdmissionQueueDepth)
+	prometheus.MustRegister(localmetrics.AdmissionShedRequests)
+	prometheus.MustRegister(localmetrics.CertificateReloads)
+	prometheus.MustRegister(localmetrics.CertificateNotAfter)
+	prometheus.MustRegister(localmetrics.CertificateNotBefore)
+	prometheus.MustRegister(localmetrics.CertificateInfo)
+	prometheus.MustRegister(localmetrics.ConfigLastReloadSuccessful)
+
+	// Including these stats kills performance when Prometheus polls with multiple targets
+	prometheus.Unregister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	prometheus.Unregister(prometheus.NewGoCollector())
+
+	if err := webhook.StartInformers(stopCh); err != nil {
+		glog.Fatalf("error starting informers: %v", err)
+	}
+	if *enablePolicies {
+		if err := webhook.StartPolicyInformer(stopCh); err != nil {
+			glog.Fatalf("error starting policy informer: %v", err)
+		}
+	}
+	if *rulesFile != "" {
+		if err := webhook.LoadRules(*rulesFile); err != nil {
+			glog.Fatalf("error loading rules: %v", err)
+		}
+		if err := webhook.WatchRules(*rulesFile, stopCh); err != nil {
+			glog.Fatalf("error watching rules: %v", err)
+		}
+	}
+	if *deprecationsFile != "" {
+		if err := webhook.LoadDeprecations(*deprecationsFile); err != nil {
+			glog.Fatalf("error loading deprecations: %v", err)
+		}
+		if err := webhook.WatchDeprecations(*deprecationsFile, stopCh); err != nil {
+			glog.Fatalf("error watching deprecations: %v", err)
 		}
-		newHashVal := hex.EncodeToString(hasher.Sum(nil))
-		if oldHashVal != "" && newHashVal != oldHashVal {
-			if err := proc.Signal(syscall.SIGHUP); err != nil {
-				glog.Fatalf("failed to send certificate update notification: %v", err)
+	}
+
+	podController := controller.NewController(ignoreNamespaces)
+	webhook.SetAttachmentCounter(podController)
+
+	// not ready while a served certificate is expired or, when the namespace of
+	// the service is known, does not cover the name the API server calls. It is
+	// only unknown when running out of a cluster.
+	serviceDNSName := ""
+	if serviceNamespace != "" {
+		serviceDNSName = fmt.Sprintf("%s.%s.svc", serviceName, serviceNamespace)
+	}
+	config.ReadinessChecks = []webhook.HealthCheck{
+		webhook.NewHealthCheck("certificate", func() error {
+			return webhook.CheckServedCertificates(serviceDNSName)
+		}),
+		webhook.NewHealthCheck("controller-synced", func() error {
+			if !podController.HasSynced() {
+				return fmt.Errorf("pod cache not synced")
+			}
+			return nil
+		}),
+		webhook.NewHealthCheck("informers-synced", webhook.InformersSynced),
+		{Name: "apiserver", Check: webhook.APIServerReachable},
+		webhook.NewHealthCheck("config-files", webhook.ConfigFilesLoaded),
+	}
+	if *enablePolicies {
+		config.ReadinessChecks = append(config.ReadinessChecks, webhook.NewHealthCheck("policy-config", webhook.PoliciesSynced))
+	}
+
+	// the webhook configurations are deregistered while the servers still answer
+	var onShutdown func()
+	if registration != nil {
+		onShutdown = func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := webhook.DeregisterWebhooks(ctx, registration); err != nil {
+				glog.Errorf("error deregistering webhooks: %v", err)
 			}
 		}
-		oldHashVal = newHashVal
+	}
 
-		time.Sleep(1 * time.Second)
+	// Start the HTTP servers (metrics and webhook) and watch for pod creations,
+	// until the process is signaled to stop
+	err = serve(ctx, config, func(ctx context.Context) {
+		controller.StartWatching(ctx, podController)
+	}, onShutdown)
+	if err != nil {
+		glog.Fatalf("error starting HTTP servers: %v", err)
 	}
+	stopWorkers()
+	glog.Infof("net-attach-def-admission-controller webhook server stopped")
+}
+
+// serve runs the HTTP servers and the controller until ctx is canceled, then
+// shuts them down in order: /readyz fails, onShutdown is called, the webhook
+// keeps serving for ShutdownDelay so that it is removed from the service
+// endpoints, then it stops accepting connections and the in-flight requests
+// are given ShutdownTimeout to complete. The controller is stopped last.
+func serve(ctx context.Context, config *ServerConfig, runController func(context.Context), onShutdown func()) error {
+	config.ReadinessChecks = append(config.ReadinessChecks, webhook.NewHealthCheck("shutdown", func() error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("shutting down")
+		}
+		return nil
+	}))
+	shutdown, err := startHTTPServers(config)
+	if err != nil {
+		return err
+	}
+
+	controllerCtx, stopController := context.WithCancel(context.Background())
+	defer stopController()
+	controllerDone := make(chan struct{})
+	go func() {
+		defer close(controllerDone)
+		runController(controllerCtx)
+	}()
 
+	<-ctx.Done()
+	glog.Infof("shutting down, serving for %v while the webhook is removed from the service endpoints", config.ShutdownDelay)
+	if onShutdown != nil {
+		onShutdown()
+	}
+	time.Sleep(config.ShutdownDelay)
+
+	glog.Infof("draining the in-flight requests for up to %v", config.ShutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	shutdown(drainCtx)
+
+	stopController()
+	<-controllerDone
+	return nil
 }
 
-func startHTTPServers(config *ServerConfig) (func(), error) {
+func startHTTPServers(config *ServerConfig) (func(context.Context), error) {
 	// Parse TLS configuration
 	tlsCipherSuiteIDs, err := cliflag.TLSCipherSuites(config.TLSCipherSuites)
 	if err != nil {
@@ -192,63 +503,112 @@ func startHTTPServers(config *ServerConfig) (func(), error) {
 		return to
 	}
 
+	applyTimeouts := func(srv *http.Server) *http.Server {
+		srv.ReadHeaderTimeout = config.ReadHeaderTimeout
+		srv.ReadTimeout = config.ReadTimeout
+		srv.WriteTimeout = config.WriteTimeout
+		srv.IdleTimeout = config.IdleTimeout
+		return srv
+	}
+
+	// Listen before serving, so that /livez and /readyz reflect whether the
+	// webhook listener is up
+	webhookAddress := fmt.Sprintf("%s:%d", config.Address, config.Port)
+	listener, err := net.Listen("tcp", webhookAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error listening on %s: %w", webhookAddress, err)
+	}
+	var listening atomic.Bool
+	listening.Store(true)
+	listenerCheck := webhook.NewHealthCheck("webhook-listener", func() error {
+		if !listening.Load() {
+			return fmt.Errorf("webhook listener on %s stopped", webhookAddress)
+		}
+		return nil
+	})
+	livez := webhook.HealthHandler("livez", append([]webhook.HealthCheck{listenerCheck}, config.LivenessChecks...)...)
+	readyz := webhook.HealthHandler("readyz", append([]webhook.HealthCheck{listenerCheck}, config.ReadinessChecks...)...)
+
 	// Start metrics server
 	var metricsServer *http.Server
 	if config.EncryptMetrics {
 		metricsServer = startHTTPMetricServer(config.MetricsAddress, applyTLSOptions(&tls.Config{
 			MinVersion: tls.VersionTLS12,
-		}))
+		}), applyTimeouts, livez, readyz, config.MetricsAuth)
 	} else {
-		metricsServer = startHTTPMetricServer(config.MetricsAddress, nil)
+		metricsServer = startHTTPMetricServer(config.MetricsAddress, nil, applyTimeouts, livez, readyz, config.MetricsAuth)
 	}
 
 	// Start webhook server
-	webhookServer := &http.Server{
-		Addr: fmt.Sprintf("%s:%d", config.Address, config.Port),
+	webhookServer := applyTimeouts(&http.Server{
+		Addr: webhookAddress,
 		TLSConfig: applyTLSOptions(&tls.Config{
-			MinVersion: tls.VersionTLS12,
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
-			},
+			MinVersion:   tls.VersionTLS12,
+			CipherSuites: webhook.DefaultCipherSuites(),
 		}),
+	})
+	if config.ClientAuth != nil {
+		config.ClientAuth.ApplyTo(webhookServer.TLSConfig)
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/validate", webhook.ValidateHandler)
 	mux.HandleFunc("/isolate", webhook.IsolateHandler)
-	webhookServer.Handler = mux
-
+	// like the server timeouts, a zero limit disables its middleware
+	var middlewares []webhook.Middleware
+	if config.MaxRequestBodyBytes > 0 {
+		middlewares = append(middlewares, webhook.LimitBodySize(config.MaxRequestBodyBytes))
+	}
+	if config.InFlightLimits.MaxInFlight > 0 {
+		middlewares = append(middlewares, webhook.LimitInFlight(config.InFlightLimits))
+	}
+	middlewares = append(middlewares, webhook.RecoverPanics())
+	if config.RequestTimeout > 0 {
+		middlewares = append(middlewares, webhook.Timeout(config.RequestTimeout))
+	}
+	// the probes are not queued or shed with the admission requests
+	root := http.NewServeMux()
+	root.Handle("/", webhook.Chain(mux, middlewares...))
+	root.Handle(livezPath, livez)
+	root.Handle(readyzPath, readyz)
+	webhookServer.Handler = root
+
+	// a stopped listener fails /livez, so that the kubelet restarts the webhook
 	go func() {
-		err := webhookServer.ListenAndServeTLS("", "")
+		err := webhookServer.ServeTLS(listener, "", "")
+		listening.Store(false)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			glog.Fatalf("error starting web server: %v", err)
+			glog.Errorf("error serving webhook: %v", err)
 		}
 	}()
 
-	return func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
+	// Shutdown closes the listeners at once, then waits for the in-flight
+	// requests until ctx is done
+	return func(ctx context.Context) {
 		if err := webhookServer.Shutdown(ctx); err != nil {
-			glog.Errorf("error shutting down webhook server: %v", err)
+			glog.Errorf("error draining webhook server, closing the remaining connections: %v", err)
+			webhookServer.Close()
 		}
 		if err := metricsServer.Shutdown(ctx); err != nil {
 			glog.Errorf("error shutting down metrics server: %v", err)
+			metricsServer.Close()
 		}
 	}, nil
 }
 
-func startHTTPMetricServer(metricsAddress string, tlsConfig *tls.Config) *http.Server {
+func startHTTPMetricServer(metricsAddress string, tlsConfig *tls.Config, applyTimeouts func(*http.Server) *http.Server, livez, readyz http.Handler, metricsAuth *webhook.DelegatedAuth) *http.Server {
 	mux := http.NewServeMux()
-	mux.Handle(metricsPath, promhttp.Handler())
+	// the probes stay anonymous, the kubelet does not send a token
+	if metricsAuth != nil {
+		mux.Handle(metricsPath, webhook.Chain(promhttp.Handler(), webhook.DelegateAuth(*metricsAuth)))
+	} else {
+		mux.Handle(metricsPath, promhttp.Handler())
+	}
 
-	// Add healthzPath
-	mux.HandleFunc(healthzPath, func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(http.StatusText(http.StatusOK)))
-	})
+	// Add the health endpoints, healthzPath is kept as an alias of livezPath
+	mux.Handle(healthzPath, livez)
+	mux.Handle(livezPath, livez)
+	mux.Handle(readyzPath, readyz)
 	// Add index
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
@@ -257,17 +617,18 @@ func startHTTPMetricServer(metricsAddress string, tlsConfig *tls.Config) *http.S
 		 <h1>Kube Metrics</h1>
 		 <ul>
 		 <li><a href='` + metricsPath + `'>metrics</a></li>
-		 <li><a href='` + healthzPath + `'>healthz</a></li>
+		 <li><a href='` + livezPath + `?verbose'>livez</a></li>
+		 <li><a href='` + readyzPath + `?verbose'>readyz</a></li>
 		 </ul>
 		 </body>
 		 </html>`))
 	})
 
-	srv := &http.Server{
+	srv := applyTimeouts(&http.Server{
 		Addr:      metricsAddress,
 		TLSConfig: tlsConfig,
 		Handler:   mux,
-	}
+	})
 
 	go func() {
 		var err error
@@ -283,3 +644,20 @@ func startHTTPMetricServer(metricsAddress string, tlsConfig *tls.Config) *http.S
 
 	return srv
 }
+
+// serviceAccountNamespaceFile holds the namespace of the pod
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// podNamespace returns the namespace of the pod, from the POD_NAMESPACE
+// environment variable set by the downward API or from the service account,
+// empty when running out of a cluster
+func podNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	data, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -15,6 +15,9 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -23,22 +26,30 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"io"
 	"math/big"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/webhook"
 )
 
 var (
 	_ = Describe("StringSliceFlag", testStringSliceFlag)
 	_ = Describe("HTTP Servers", testHTTPServers)
+	_ = Describe("Lifecycle", testLifecycle)
+	_ = Describe("Simulation", testSimulation)
+	_ = Describe("Pod namespace", testPodNamespace)
 )
 
 func TestMain(t *testing.T) {
@@ -74,12 +85,45 @@ func testStringSliceFlag() {
 	)
 }
 
+func testSimulation() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "simulate")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "nad.yaml"), []byte(`
+apiVersion: k8s.cni.cncf.io/v1
+kind: NetworkAttachmentDefinition
+metadata:
+  name: broken
+spec:
+  config: '{"cniVersion": "0.3.1"}'
+`), 0644)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should report the objects of a directory", func() {
+		var out bytes.Buffer
+		Expect(runSimulation(&out, dir, "json", "", false)).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(`"rule": "config"`))
+		Expect(out.String()).To(ContainSubstring(`"name": "broken"`))
+	})
+
+	It("should reject unknown output formats", func() {
+		Expect(runSimulation(&bytes.Buffer{}, dir, "csv", "", false)).To(MatchError(ContainSubstring(`invalid output format "csv"`)))
+	})
+}
+
 func testHTTPServers() {
 	var (
 		certFile string
 		keyFile  string
 		config   *ServerConfig
-		cleanup  func()
+		cleanup  func(context.Context)
 	)
 
 	BeforeEach(func() {
@@ -105,7 +149,7 @@ func testHTTPServers() {
 
 	AfterEach(func() {
 		if cleanup != nil {
-			cleanup()
+			cleanup(context.Background())
 		}
 		if certFile != "" {
 			_ = os.Remove(certFile)
@@ -167,7 +211,7 @@ func testHTTPServers() {
 		})
 
 		It("should serve endpoints over HTTPS", func() {
-			for _, endpoint := range []string{"/validate", "/isolate"} {
+			for _, endpoint := range []string{"/validate", "/isolate", "/livez", "/readyz"} {
 				testHTTPSEndpoint(bindAddress, endpoint)
 			}
 		})
@@ -246,15 +290,229 @@ func testHTTPServers() {
 					testHTTPSEndpoint(config.MetricsAddress, endpoint)
 				}
 			})
+
+			Context("and authentication enabled", func() {
+				BeforeEach(func() {
+					config.MetricsAuth = &webhook.DelegatedAuth{}
+				})
+
+				It("should only require a token for the metrics", func() {
+					client := &http.Client{
+						Transport: &http.Transport{
+							TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+						},
+					}
+					Eventually(func(g Gomega) {
+						for endpoint, status := range map[string]int{"/metrics": http.StatusUnauthorized, "/healthz": http.StatusOK, "/readyz": http.StatusOK} {
+							resp, err := client.Get(fmt.Sprintf("https://%s%s", config.MetricsAddress, endpoint))
+							g.Expect(err).NotTo(HaveOccurred())
+							resp.Body.Close()
+							g.Expect(resp.StatusCode).To(Equal(status), endpoint)
+						}
+					}).Within(5 * time.Second).Should(Succeed())
+				})
+			})
 		})
 
 		Context("with encryption disabled", func() {
 			It("should serve endpoints over plain HTTP", func() {
-				for _, endpoint := range []string{"/metrics", "/healthz"} {
+				for _, endpoint := range []string{"/metrics", "/healthz", "/livez", "/readyz"} {
 					testHTTPEndpoint(config.MetricsAddress, endpoint)
 				}
 			})
 		})
+
+		Context("while not ready", func() {
+			BeforeEach(func() {
+				config.ReadinessChecks = []webhook.HealthCheck{
+					webhook.NewHealthCheck("certificate", func() error { return fmt.Errorf("no certificate loaded") }),
+				}
+			})
+
+			It("should fail readiness listing each check", func() {
+				Eventually(func(g Gomega) {
+					resp, err := http.Get(fmt.Sprintf("http://%s%s", config.MetricsAddress, "/readyz"))
+					g.Expect(err).NotTo(HaveOccurred())
+					defer resp.Body.Close()
+					g.Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+					body, err := io.ReadAll(resp.Body)
+					g.Expect(err).NotTo(HaveOccurred())
+					g.Expect(string(body)).To(Equal("[+]webhook-listener ok\n[-]certificate failed: no certificate loaded\nreadyz check failed\n"))
+				}).Within(5 * time.Second).Should(Succeed())
+
+				resp, err := http.Get(fmt.Sprintf("http://%s%s", config.MetricsAddress, "/livez?verbose"))
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				body, err := io.ReadAll(resp.Body)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(body)).To(Equal("[+]webhook-listener ok\nlivez check passed\n"))
+			})
+		})
+	})
+}
+
+func testLifecycle() {
+	var (
+		certFile       string
+		keyFile        string
+		config         *ServerConfig
+		webhookAddress string
+		ctx            context.Context
+		cancel         context.CancelFunc
+		done           chan struct{}
+		result         error
+
+		eventsMutex sync.Mutex
+		events      []string
+	)
+
+	record := func(event string) {
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+		events = append(events, event)
+	}
+	recorded := func() []string {
+		eventsMutex.Lock()
+		defer eventsMutex.Unlock()
+		return append([]string{}, events...)
+	}
+
+	readyz := func() (int, string) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/readyz", config.MetricsAddress))
+		if err != nil {
+			return 0, err.Error()
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	readyzStatus := func() int {
+		status, _ := readyz()
+		return status
+	}
+
+	dial := func() error {
+		conn, err := tls.Dial("tcp", webhookAddress, &tls.Config{InsecureSkipVerify: true})
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	start := func() {
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			result = serve(ctx, config, func(ctx context.Context) {
+				record("controller started")
+				<-ctx.Done()
+				record("controller stopped")
+			}, func() {
+				record("shutdown started")
+			})
+		}()
+		Eventually(readyzStatus).Within(5 * time.Second).Should(Equal(http.StatusOK))
+	}
+
+	// startReview sends the headers and the start of the body of a review,
+	// which stays in flight until finish sends the rest
+	startReview := func() (finish func() (*http.Response, error)) {
+		conn, err := tls.Dial("tcp", webhookAddress, &tls.Config{InsecureSkipVerify: true})
+		Expect(err).NotTo(HaveOccurred())
+		body := `{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`
+		_, err = fmt.Fprintf(conn, "POST /validate HTTP/1.1\r\nHost: webhook\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(body), body[:10])
+		Expect(err).NotTo(HaveOccurred())
+		return func() (*http.Response, error) {
+			defer conn.Close()
+			if _, err := conn.Write([]byte(body[10:])); err != nil {
+				return nil, err
+			}
+			return http.ReadResponse(bufio.NewReader(conn), nil)
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		certFile, keyFile, err = generateTestCertificate()
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		Expect(err).NotTo(HaveOccurred())
+
+		config = &ServerConfig{
+			Address:         "127.0.0.1",
+			MetricsAddress:  net.JoinHostPort("127.0.0.5", strconv.Itoa(getFreePort("127.0.0.5"))),
+			ShutdownDelay:   500 * time.Millisecond,
+			ShutdownTimeout: 5 * time.Second,
+			GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+				return &cert, nil
+			},
+		}
+		config.Port = getFreePort(config.Address)
+		webhookAddress = net.JoinHostPort(config.Address, strconv.Itoa(config.Port))
+
+		ctx, cancel = context.WithCancel(context.Background())
+		done = nil
+		result = nil
+		events = nil
+	})
+
+	AfterEach(func() {
+		cancel()
+		if done != nil {
+			Eventually(done).Within(10 * time.Second).Should(BeClosed())
+		}
+		_ = os.Remove(certFile)
+		_ = os.Remove(keyFile)
+	})
+
+	It("should fail readiness, then drain the in-flight reviews and stop the controller last", func() {
+		start()
+		finish := startReview()
+
+		cancel()
+		Eventually(readyzStatus).Should(Equal(http.StatusServiceUnavailable))
+		_, body := readyz()
+		Expect(body).To(ContainSubstring("[-]shutdown failed: shutting down"))
+		// the webhook still accepts connections until it is removed from the endpoints
+		Expect(dial()).To(Succeed())
+		Eventually(recorded).Should(Equal([]string{"controller started", "shutdown started"}))
+
+		// then it stops accepting connections, but waits for the in-flight review
+		Eventually(dial).Within(5 * time.Second).ShouldNot(Succeed())
+		Consistently(done, 200*time.Millisecond).ShouldNot(BeClosed())
+		Expect(recorded()).NotTo(ContainElement("controller stopped"))
+
+		resp, err := finish()
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).NotTo(Equal(http.StatusNotFound))
+
+		Eventually(done).Within(5 * time.Second).Should(BeClosed())
+		Expect(result).NotTo(HaveOccurred())
+		Expect(recorded()).To(Equal([]string{"controller started", "shutdown started", "controller stopped"}))
+	})
+
+	It("should close the reviews still in flight after the shutdown timeout", func() {
+		config.ShutdownDelay = 0
+		config.ShutdownTimeout = 300 * time.Millisecond
+		start()
+		finish := startReview()
+
+		cancel()
+		Eventually(done).Within(5 * time.Second).Should(BeClosed())
+		Expect(recorded()).To(Equal([]string{"controller started", "shutdown started", "controller stopped"}))
+		_, err := finish()
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should not start the controller when the servers fail to start", func() {
+		listener, err := net.Listen("tcp", webhookAddress)
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+
+		Expect(serve(ctx, config, func(context.Context) { record("controller started") }, nil)).To(MatchError(ContainSubstring("error listening on")))
+		Expect(recorded()).To(BeEmpty())
 	})
 }
 
@@ -398,3 +656,36 @@ func generateTestCertificate() (string, string, error) {
 
 	return certFile.Name(), keyFile.Name(), nil
 }
+
+func testPodNamespace() {
+	var oldFile, dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "namespace")
+		Expect(err).NotTo(HaveOccurred())
+		oldFile = serviceAccountNamespaceFile
+		serviceAccountNamespaceFile = filepath.Join(dir, "namespace")
+	})
+
+	AfterEach(func() {
+		serviceAccountNamespaceFile = oldFile
+		os.Unsetenv("POD_NAMESPACE")
+		os.RemoveAll(dir)
+	})
+
+	It("should be unknown out of a cluster", func() {
+		Expect(podNamespace()).To(BeEmpty())
+	})
+
+	It("should read the namespace of the service account", func() {
+		Expect(os.WriteFile(serviceAccountNamespaceFile, []byte("kube-system\n"), 0644)).To(Succeed())
+		Expect(podNamespace()).To(Equal("kube-system"))
+	})
+
+	It("should prefer the downward API", func() {
+		Expect(os.WriteFile(serviceAccountNamespaceFile, []byte("kube-system"), 0644)).To(Succeed())
+		os.Setenv("POD_NAMESPACE", "network")
+		Expect(podNamespace()).To(Equal("network"))
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/simulation"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/webhook"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// runSimulation checks the objects of the cluster, or of the directory if set,
+// with the configured checks and writes the report of would-be denials
+func runSimulation(w io.Writer, dir, output, rulesFile string, policies bool) error {
+	var write func(*simulation.Report, io.Writer) error
+	switch output {
+	case "table":
+		write = (*simulation.Report).WriteTable
+	case "json":
+		write = (*simulation.Report).WriteJSON
+	case "junit":
+		write = (*simulation.Report).WriteJUnit
+	default:
+		return fmt.Errorf("invalid output format %q, must be one of table, json, junit", output)
+	}
+
+	if rulesFile != "" {
+		if err := webhook.LoadRules(rulesFile); err != nil {
+			return err
+		}
+	}
+
+	var objects *simulation.Objects
+	var err error
+	if dir != "" {
+		objects, err = simulation.LoadDirectory(dir)
+	} else {
+		config, configErr := clientcmd.BuildConfigFromFlags("", os.Getenv("KUBECONFIG"))
+		if configErr != nil {
+			return configErr
+		}
+		objects, err = simulation.LoadCluster(config, policies)
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := simulation.Run(objects, policies)
+	if err != nil {
+		return err
+	}
+	return write(report, w)
+}
@@ -19,11 +19,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/signal"
 	"regexp"
 	"sort"
 	"strings"
-	"syscall"
+	"sync"
 	"time"
 
 	"github.com/containernetworking/cni/libcni"
@@ -46,6 +45,10 @@ import (
 const (
 	maxRetries       = 5
 	nadPodAnnotation = "k8s.v1.cni.cncf.io/networks"
+	// networkIndex indexes pods by the namespace/name keys of the networks they are attached to
+	networkIndex = "network"
+	// queueDrainTimeout is how long the queued pods are given to be processed once stopped
+	queueDrainTimeout = 5 * time.Second
 )
 
 type metricAction int
@@ -68,10 +71,18 @@ type Controller struct {
 	queue        workqueue.RateLimitingInterface
 	informer     cache.SharedIndexInformer
 	nadClientset *netattachdefClientset.Clientset
+	// podNetworks keeps the network keys of each processed pod, so that the
+	// attachment metrics can be updated once the pod is gone
+	podNetworks map[string][]string
+	// ignoreNamespaces are the namespaces whose pods are only counted as attachments
+	ignoreNamespaces map[string]struct{}
+	// drainTimeout bounds the processing of the queued pods once stopped
+	drainTimeout time.Duration
 }
 
-// StartWatching ...  Start prepares watchers and run their controllers, then waits for process termination signals
-func StartWatching(ignoreNamespaces []string) {
+// NewController ... prepares the pod watcher of the pods that have not terminated.
+// The pods in ignoreNamespaces are only counted as network attachments.
+func NewController(ignoreNamespaces []string) *Controller {
 	var clientset kubernetes.Interface
 
 	// setup Kubernetes API client
@@ -89,14 +100,9 @@ func StartWatching(ignoreNamespaces []string) {
 	if err != nil {
 		glog.Fatalf("There was error accessing client set for net attach def %v", err)
 	}
-	// Initialize default metrics
-	localmetrics.InitMetrics()
-
-	// add fieldSelector to filter the non-target namespaces
-	fieldSelector := "status.phase==Running"
-	for _, ns := range ignoreNamespaces {
-		fieldSelector = fmt.Sprintf("%s,metadata.namespace!=%s", fieldSelector, ns)
-	}
+	// pending pods are watched too, as they are attached to their networks
+	// as soon as they are admitted
+	fieldSelector := fmt.Sprintf("status.phase!=%s,status.phase!=%s", api_v1.PodSucceeded, api_v1.PodFailed)
 
 	informer := cache.NewSharedIndexInformer(
 		cache.NewFilteredListWatchFromClient(
@@ -110,18 +116,20 @@ func StartWatching(ignoreNamespaces []string) {
 		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, // use default indexer
 	)
 
-	c := newResourceController(clientset, nadClientset, informer)
-	stopCh := make(chan struct{})
-	defer close(stopCh)
-	go c.Run(stopCh)
+	return newResourceController(clientset, nadClientset, informer, ignoreNamespaces)
+}
+
+// StartWatching ...  runs the controller until ctx is done, and returns once
+// its queue is shut down
+func StartWatching(ctx context.Context, c *Controller) {
+	// Initialize default metrics
+	localmetrics.InitMetrics()
 
-	sigterm := make(chan os.Signal, 1)
-	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM, syscall.SIGKILL)
-	<-sigterm
+	c.Run(ctx.Done())
 }
 
 func newResourceController(client kubernetes.Interface, nadClient *netattachdefClientset.Clientset,
-	informer cache.SharedIndexInformer) *Controller {
+	informer cache.SharedIndexInformer, ignoreNamespaces []string) *Controller {
 	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 
 	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -182,31 +190,145 @@ func newResourceController(client kubernetes.Interface, nadClient *netattachdefC
 		},
 	})
 
-	return &Controller{
-		clientset:    client,
-		nadClientset: nadClient,
-		informer:     informer,
-		queue:        queue,
+	c := &Controller{
+		clientset:        client,
+		nadClientset:     nadClient,
+		informer:         informer,
+		queue:            queue,
+		podNetworks:      map[string][]string{},
+		ignoreNamespaces: map[string]struct{}{},
+		drainTimeout:     queueDrainTimeout,
+	}
+	for _, ns := range ignoreNamespaces {
+		c.ignoreNamespaces[ns] = struct{}{}
+	}
+
+	if err := informer.AddIndexers(cache.Indexers{networkIndex: c.networkIndexFunc}); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to add network index: %v", err))
+	}
+
+	return c
+}
+
+// networkIndexFunc returns the namespace/name keys of the networks the pod is attached to
+func (c *Controller) networkIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*api_v1.Pod)
+	if !ok {
+		return nil, nil
+	}
+	return c.podNetworkKeys(pod), nil
+}
+
+// podNetworkKeys returns the unique namespace/name keys of the networks requested by the pod
+func (c *Controller) podNetworkKeys(pod *api_v1.Pod) []string {
+	annotation, ok := pod.GetAnnotations()[nadPodAnnotation]
+	if !ok {
+		return nil
+	}
+	networks, err := c.parsePodNetworkAnnotation(annotation, pod.GetNamespace())
+	if err != nil {
+		return nil
+	}
+
+	var keys []string
+	set := make(map[string]struct{})
+	for _, network := range networks {
+		key := network.Namespace + "/" + network.Name
+		if _, found := set[key]; !found {
+			set[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// AttachedPods returns the keys of the pods attached to the net-attach-def that
+// have not terminated
+func (c *Controller) AttachedPods(namespace, name string) []string {
+	keys, err := c.informer.GetIndexer().IndexKeys(networkIndex, namespace+"/"+name)
+	if err != nil {
+		return nil
 	}
+	return keys
+}
+
+// NetworkAttachmentCount returns the number of pods attached to the net-attach-def
+// that have not terminated
+func (c *Controller) NetworkAttachmentCount(namespace, name string) int {
+	return len(c.AttachedPods(namespace, name))
 }
 
-// Run starts the kubewatch controller
+// updateAttachmentMetrics refreshes the attachment metrics of the networks the pod
+// was or is attached to
+func (c *Controller) updateAttachmentMetrics(key string, pod *api_v1.Pod) {
+	var networks []string
+	if pod != nil {
+		networks = c.podNetworkKeys(pod)
+	}
+
+	affected := make(map[string]struct{})
+	for _, network := range c.podNetworks[key] {
+		affected[network] = struct{}{}
+	}
+	for _, network := range networks {
+		affected[network] = struct{}{}
+	}
+
+	if len(networks) > 0 {
+		c.podNetworks[key] = networks
+	} else {
+		delete(c.podNetworks, key)
+	}
+
+	for network := range affected {
+		namespace, name, err := cache.SplitMetaNamespaceKey(network)
+		if err != nil {
+			continue
+		}
+		localmetrics.SetNetAttachDefAttachments(namespace, name, c.NetworkAttachmentCount(namespace, name))
+	}
+}
+
+// Run starts the kubewatch controller and blocks until stopCh is closed. The
+// queue is then shut down once the queued pods are processed.
 func (c *Controller) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
-	defer c.queue.ShutDown()
 
 	glog.Info("Starting net-attach-def-admission-controller")
 
 	go c.informer.Run(stopCh)
 
 	if !cache.WaitForCacheSync(stopCh, c.HasSynced) {
+		c.queue.ShutDown()
 		utilruntime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 		return
 	}
 
 	glog.Info("net-attach-def-admission-controller synced and ready")
 
-	wait.Until(c.runWorker, time.Second, stopCh)
+	var workers sync.WaitGroup
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		wait.Until(c.runWorker, time.Second, stopCh)
+	}()
+
+	<-stopCh
+	glog.Infof("shutting down the net-attach-def-admission-controller queue, processing the queued pods for up to %v", c.drainTimeout)
+	// the worker keeps getting the queued pods until the queue is empty
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		c.queue.ShutDownWithDrain()
+		workers.Wait()
+	}()
+	select {
+	case <-drained:
+		glog.Info("net-attach-def-admission-controller stopped")
+	case <-time.After(c.drainTimeout):
+		c.queue.ShutDown()
+		glog.Warningf("net-attach-def-admission-controller stopped with %d pods left in its queue after %v", c.queue.Len(), c.drainTimeout)
+	}
 }
 
 // HasSynced is required for the cache.Controller interface.
@@ -275,11 +397,16 @@ func (c *Controller) processItem(key string) error {
 		return fmt.Errorf("Error fetching object with key %s from store: %v", key, err)
 	}
 	if !exists {
+		c.updateAttachmentMetrics(key, nil)
 		return c.updateMetrics(key, "", api_v1.NamespaceDefault, Delete)
 	}
 
 	pod, _ := obj.(*api_v1.Pod)
+	c.updateAttachmentMetrics(key, pod)
 	namespace := pod.ObjectMeta.Namespace
+	if _, ignored := c.ignoreNamespaces[namespace]; ignored {
+		return nil
+	}
 	if pod.Status.Phase == api_v1.PodRunning {
 		glog.Infof("Pod found for net-attach-def metrics, processing %s under namespaces %s", key, namespace)
 		if name, ok := pod.GetAnnotations()[nadPodAnnotation]; ok {
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	api_v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newRunningPod(namespace, name, networks string) *api_v1.Pod {
+	return &api_v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{nadPodAnnotation: networks},
+		},
+		Status: api_v1.PodStatus{Phase: api_v1.PodRunning},
+	}
+}
+
+func gaugeValue(gauge prometheus.Gauge) float64 {
+	metric := &dto.Metric{}
+	Expect(gauge.Write(metric)).To(Succeed())
+	return metric.GetGauge().GetValue()
+}
+
+var _ = Describe("Controller", func() {
+	var c *Controller
+
+	BeforeEach(func() {
+		informer := cache.NewSharedIndexInformer(&cache.ListWatch{}, &api_v1.Pod{}, 0,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		c = newResourceController(nil, nil, informer, []string{"kube-system"})
+	})
+
+	Describe("Counting network attachments", func() {
+		It("should count running pods per net-attach-def", func() {
+			indexer := c.informer.GetIndexer()
+			Expect(indexer.Add(newRunningPod("tenant", "pod1", "net1,net1@net2"))).To(Succeed())
+			Expect(indexer.Add(newRunningPod("tenant", "pod2", `[{"name": "net1"}, {"name": "net2", "namespace": "infra"}]`))).To(Succeed())
+			Expect(indexer.Add(newRunningPod("infra", "pod3", "net2"))).To(Succeed())
+
+			Expect(c.NetworkAttachmentCount("tenant", "net1")).To(Equal(2))
+			Expect(c.NetworkAttachmentCount("infra", "net2")).To(Equal(2))
+			Expect(c.NetworkAttachmentCount("tenant", "net2")).To(Equal(0))
+			Expect(c.AttachedPods("infra", "net2")).To(ConsistOf("tenant/pod2", "infra/pod3"))
+		})
+
+		It("should count pending pods and pods in ignored namespaces", func() {
+			indexer := c.informer.GetIndexer()
+			pending := newRunningPod("tenant", "pod1", "infra/net1")
+			pending.Status.Phase = api_v1.PodPending
+			Expect(indexer.Add(pending)).To(Succeed())
+			ignored := newRunningPod("kube-system", "pod2", "infra/net1")
+			Expect(indexer.Add(ignored)).To(Succeed())
+
+			Expect(c.NetworkAttachmentCount("infra", "net1")).To(Equal(2))
+			// the instance metrics of the ignored pods are not updated
+			Expect(c.processItem("kube-system/pod2")).To(Succeed())
+			Expect(localmetrics.GetStoredValue("kube-system/pod2")).To(BeEmpty())
+			Expect(gaugeValue(localmetrics.NetAttachDefAttachments.WithLabelValues("infra", "net1"))).To(Equal(2.0))
+		})
+	})
+
+	Describe("Attachment metrics", func() {
+		It("should follow pods being added and removed", func() {
+			indexer := c.informer.GetIndexer()
+			pod := newRunningPod("tenant", "pod1", "net1")
+			Expect(indexer.Add(pod)).To(Succeed())
+			Expect(indexer.Add(newRunningPod("tenant", "pod2", "net1"))).To(Succeed())
+
+			c.updateAttachmentMetrics("tenant/pod1", pod)
+			Expect(gaugeValue(localmetrics.NetAttachDefAttachments.WithLabelValues("tenant", "net1"))).To(Equal(2.0))
+
+			Expect(indexer.Delete(pod)).To(Succeed())
+			c.updateAttachmentMetrics("tenant/pod1", nil)
+			Expect(gaugeValue(localmetrics.NetAttachDefAttachments.WithLabelValues("tenant", "net1"))).To(Equal(1.0))
+			Expect(c.podNetworks).NotTo(HaveKey("tenant/pod1"))
+		})
+	})
+
+	Describe("Running", func() {
+		BeforeEach(func() {
+			informer := cache.NewSharedIndexInformer(&cache.ListWatch{
+				ListFunc: func(options meta_v1.ListOptions) (runtime.Object, error) {
+					return &api_v1.PodList{}, nil
+				},
+				WatchFunc: func(options meta_v1.ListOptions) (watch.Interface, error) {
+					return watch.NewFake(), nil
+				},
+			}, &api_v1.Pod{}, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			c = newResourceController(nil, nil, informer, []string{"kube-system"})
+		})
+
+		It("should process the queued pods before returning once stopped", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				StartWatching(ctx, c)
+			}()
+			Eventually(c.HasSynced).Should(BeTrue())
+			Consistently(done).ShouldNot(BeClosed())
+
+			for i := 0; i < 100; i++ {
+				c.queue.Add(fmt.Sprintf("tenant/gone%d", i))
+			}
+			cancel()
+			Eventually(done).Should(BeClosed())
+			Expect(c.queue.ShuttingDown()).To(BeTrue())
+			Expect(c.queue.Len()).To(Equal(0))
+		})
+
+		It("should return after the drain timeout when a pod is still processed", func() {
+			c.drainTimeout = 200 * time.Millisecond
+			c.queue.Add("tenant/stuck")
+			key, _ := c.queue.Get()
+			defer c.queue.Done(key)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				StartWatching(ctx, c)
+			}()
+			Eventually(c.HasSynced).Should(BeTrue())
+			Consistently(done, 200*time.Millisecond).ShouldNot(BeClosed())
+
+			cancel()
+			Consistently(done, 100*time.Millisecond).ShouldNot(BeClosed())
+			Eventually(done, time.Second).Should(BeClosed())
+			Expect(c.queue.ShuttingDown()).To(BeTrue())
+		})
+	})
+})
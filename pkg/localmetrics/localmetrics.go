@@ -15,6 +15,8 @@
 package localmetrics
 
 import (
+	"time"
+
 	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -43,6 +45,72 @@ var (
 			Name: "network_attachment_definition_enabled_instance_up",
 			Help: "Metric to identify clusters with network attachment definition enabled instances.",
 		}, []string{"networks"})
+	// NetAttachDefAttachments ... number of pending and running pods attached to each network attachment definition
+	NetAttachDefAttachments = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_attachment_definition_attachments",
+			Help: "Metric to get number of pending and running pods attached to a network attachment definition.",
+		}, []string{"namespace", "name"})
+	// AdmissionCheckFailures ... number of failed admission checks by enforcement mode
+	AdmissionCheckFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_attachment_definition_admission_check_failures_total",
+			Help: "Metric to get number of failed admission checks by check and enforcement mode.",
+		}, []string{"check", "mode"})
+	// DeprecatedFeatureUses ... number of requests using a deprecated feature
+	DeprecatedFeatureUses = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_attachment_definition_deprecated_feature_uses_total",
+			Help: "Metric to get number of admission requests using a deprecated feature by feature and namespace.",
+		}, []string{"feature", "namespace"})
+	// AdmissionQueueDepth ... number of admission requests waiting to be handled
+	AdmissionQueueDepth = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "network_attachment_definition_admission_queue_depth",
+			Help: "Metric to get number of admission requests waiting for an in-flight request to finish.",
+		})
+	// AdmissionShedRequests ... number of admission requests shed because of overload
+	AdmissionShedRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_attachment_definition_admission_shed_requests_total",
+			Help: "Metric to get number of admission requests that were not handled because of overload by reason and overload behavior.",
+		}, []string{"reason", "behavior"})
+	// CertificateReloads ... number of TLS certificate reloads by result
+	CertificateReloads = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "network_attachment_definition_tls_certificate_reloads_total",
+			Help: "Metric to get number of TLS serving certificate reloads by result.",
+		}, []string{"result"})
+	// CertificateNotAfter ... expiry time of each served TLS certificate
+	CertificateNotAfter = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_attachment_definition_tls_certificate_not_after_timestamp_seconds",
+			Help: "Metric to get the time after which a served TLS certificate is no longer valid, in seconds since the epoch.",
+		}, []string{"certificate"})
+	// CertificateNotBefore ... start of validity of each served TLS certificate
+	CertificateNotBefore = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_attachment_definition_tls_certificate_not_before_timestamp_seconds",
+			Help: "Metric to get the time before which a served TLS certificate is not yet valid, in seconds since the epoch.",
+		}, []string{"certificate"})
+	// CertificateInfo ... serial number of each served TLS certificate
+	CertificateInfo = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_attachment_definition_tls_certificate_info",
+			Help: "Metric to get the serial number of a served TLS certificate, always 1.",
+		}, []string{"certificate", "serial"})
+	// ConfigLastReloadSuccessful ... whether the last reload of each watched configuration file succeeded
+	ConfigLastReloadSuccessful = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "network_attachment_definition_config_last_reload_successful",
+			Help: "Metric to identify whether the last reload of a configuration file succeeded, the previous configuration is in use otherwise.",
+		}, []string{"file"})
+)
+
+// results of certificate reloads
+const (
+	ReloadSuccess = "success"
+	ReloadFailure = "failure"
 )
 
 // UpdateNetAttachDefInstanceMetrics ...
@@ -83,6 +151,66 @@ func SetNetAttachDefEnabledInstanceUp(tp string, val int) {
 		"networks": tp}).Set(float64(val))
 }
 
+// SetNetAttachDefAttachments ... set the number of pods attached to a network attachment definition
+func SetNetAttachDefAttachments(namespace string, name string, val int) {
+	if val == 0 {
+		NetAttachDefAttachments.DeleteLabelValues(namespace, name)
+		return
+	}
+	NetAttachDefAttachments.With(prometheus.Labels{
+		"namespace": namespace, "name": name}).Set(float64(val))
+}
+
+// IncAdmissionCheckFailures ... count a failed admission check
+func IncAdmissionCheckFailures(check string, mode string) {
+	AdmissionCheckFailures.With(prometheus.Labels{
+		"check": check, "mode": mode}).Inc()
+}
+
+// IncDeprecatedFeatureUses ... count a request using a deprecated feature
+func IncDeprecatedFeatureUses(feature string, namespace string) {
+	DeprecatedFeatureUses.With(prometheus.Labels{
+		"feature": feature, "namespace": namespace}).Inc()
+}
+
+// SetAdmissionQueueDepth ... set the number of queued admission requests
+func SetAdmissionQueueDepth(val int) {
+	AdmissionQueueDepth.Set(float64(val))
+}
+
+// IncAdmissionShedRequests ... count an admission request shed because of overload
+func IncAdmissionShedRequests(reason string, behavior string) {
+	AdmissionShedRequests.With(prometheus.Labels{
+		"reason": reason, "behavior": behavior}).Inc()
+}
+
+// IncCertificateReloads ... count a TLS certificate reload
+func IncCertificateReloads(result string) {
+	CertificateReloads.With(prometheus.Labels{
+		"result": result}).Inc()
+}
+
+// SetCertificateValidity ... set the validity period and serial number of a
+// served TLS certificate, replacing the ones of the certificate it replaces
+func SetCertificateValidity(certificate string, notBefore, notAfter time.Time, serial string) {
+	labels := prometheus.Labels{"certificate": certificate}
+	CertificateNotBefore.With(labels).Set(float64(notBefore.Unix()))
+	CertificateNotAfter.With(labels).Set(float64(notAfter.Unix()))
+	CertificateInfo.DeletePartialMatch(labels)
+	CertificateInfo.With(prometheus.Labels{
+		"certificate": certificate, "serial": serial}).Set(1)
+}
+
+// SetConfigLastReloadSuccessful ... set whether the last reload of a configuration file succeeded
+func SetConfigLastReloadSuccessful(file string, successful bool) {
+	val := 0
+	if successful {
+		val = 1
+	}
+	ConfigLastReloadSuccessful.With(prometheus.Labels{
+		"file": file}).Set(float64(val))
+}
+
 // InitMetrics ... empty metrics
 func InitMetrics() {
 	UpdateNetAttachDefInstanceMetrics("any", initialMetricsCount)
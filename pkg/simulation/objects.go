@@ -0,0 +1,195 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simulation runs the admission checks on existing objects and
+// reports the requests that would be denied.
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/webhook"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	netattachdefClientset "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Objects are the objects a simulation runs on
+type Objects struct {
+	Namespaces    []*v1.Namespace
+	NetAttachDefs []*netv1.NetworkAttachmentDefinition
+	Pods          []*v1.Pod
+	Policies      []*webhook.NetworkAttachmentPolicy
+}
+
+// LoadCluster lists the objects from the API server, policies are only
+// listed if enabled since their CRD may not be installed
+func LoadCluster(config *rest.Config, policies bool) (*Objects, error) {
+	ctx := context.TODO()
+	objects := &Objects{}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	nadClient, err := netattachdefClientset.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing namespaces: %v", err)
+	}
+	for i := range namespaces.Items {
+		objects.Namespaces = append(objects.Namespaces, &namespaces.Items[i])
+	}
+
+	netAttachDefs, err := nadClient.K8sCniCncfIoV1().NetworkAttachmentDefinitions(v1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing net-attach-defs: %v", err)
+	}
+	for i := range netAttachDefs.Items {
+		objects.NetAttachDefs = append(objects.NetAttachDefs, &netAttachDefs.Items[i])
+	}
+
+	pods, err := client.CoreV1().Pods(v1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing pods: %v", err)
+	}
+	for i := range pods.Items {
+		objects.Pods = append(objects.Pods, &pods.Items[i])
+	}
+
+	if policies {
+		dynamicClient, err := dynamic.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		list, err := dynamicClient.Resource(webhook.PolicyResource).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("error listing network attachment policies: %v", err)
+		}
+		for i := range list.Items {
+			if err := objects.add(&list.Items[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return objects, nil
+}
+
+// LoadDirectory reads the objects from the YAML and JSON files in the directory
+// and its subdirectories. Files may hold several documents and List objects,
+// objects of other kinds are ignored.
+func LoadDirectory(dir string) (*Objects, error) {
+	objects := &Objects{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := objects.decode(f); err != nil {
+			return fmt.Errorf("error reading %s: %v", path, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+// decode adds every document of the stream
+func (o *Objects) decode(r io.Reader) error {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		if u.IsList() {
+			err := u.EachListItem(func(item runtime.Object) error {
+				return o.add(item.(*unstructured.Unstructured))
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := o.add(u); err != nil {
+			return err
+		}
+	}
+}
+
+// add converts the object to its type and adds it
+func (o *Objects) add(u *unstructured.Unstructured) error {
+	var obj interface{}
+	switch u.GetKind() {
+	case "Namespace":
+		ns := &v1.Namespace{}
+		o.Namespaces = append(o.Namespaces, ns)
+		obj = ns
+	case "NetworkAttachmentDefinition":
+		netAttachDef := &netv1.NetworkAttachmentDefinition{}
+		o.NetAttachDefs = append(o.NetAttachDefs, netAttachDef)
+		obj = netAttachDef
+	case "Pod":
+		pod := &v1.Pod{}
+		o.Pods = append(o.Pods, pod)
+		obj = pod
+	case "NetworkAttachmentPolicy":
+		policy := &webhook.NetworkAttachmentPolicy{}
+		o.Policies = append(o.Policies, policy)
+		obj = policy
+	default:
+		return nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+		return fmt.Errorf("failed to convert %s %s: %v", u.GetKind(), u.GetName(), err)
+	}
+	return nil
+}
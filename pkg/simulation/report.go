@@ -0,0 +1,224 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/webhook"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	kindNetAttachDef = "NetworkAttachmentDefinition"
+	kindPod          = "Pod"
+)
+
+// Denial is an object whose request would be denied by a check
+type Denial struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// RuleGroup holds the denials of a check, or of a CEL rule
+type RuleGroup struct {
+	// Rule is the check name, followed by the rule name for CEL rules
+	Rule    string   `json:"rule"`
+	Denials []Denial `json:"denials"`
+}
+
+// NamespaceGroup holds the denials in a namespace
+type NamespaceGroup struct {
+	Namespace string      `json:"namespace"`
+	Rules     []RuleGroup `json:"rules"`
+}
+
+// Report lists the would-be denials grouped by namespace and rule, both sorted by name
+type Report struct {
+	// Checked is the number of objects that were checked
+	Checked    int              `json:"checked"`
+	Namespaces []NamespaceGroup `json:"namespaces"`
+}
+
+// Run checks every net-attach-def and pod, as if they were updated, against the
+// current configuration of the webhook package and reports the failures whatever
+// their enforcement mode. Policies are only checked if enabled.
+func Run(objects *Objects, policies bool) (*Report, error) {
+	enabledPolicies := objects.Policies
+	if policies && enabledPolicies == nil {
+		enabledPolicies = []*webhook.NetworkAttachmentPolicy{}
+	} else if !policies {
+		enabledPolicies = nil
+	}
+	for _, netAttachDef := range objects.NetAttachDefs {
+		if netAttachDef.GetNamespace() == "" {
+			netAttachDef.SetNamespace(v1.NamespaceDefault)
+		}
+	}
+	for _, pod := range objects.Pods {
+		if pod.GetNamespace() == "" {
+			pod.SetNamespace(v1.NamespaceDefault)
+		}
+	}
+	if err := webhook.SetCaches(objects.Namespaces, objects.NetAttachDefs, enabledPolicies); err != nil {
+		return nil, err
+	}
+	// the attachments of each pod are counted among the other pods
+	webhook.SetAttachmentCounter(webhook.NewPodAttachmentCounter(objects.Pods))
+
+	denials := map[string]map[string][]Denial{}
+	add := func(namespace, kind, name string, failures []webhook.CheckFailure) {
+		for _, failure := range failures {
+			rule := failure.Check
+			if failure.Rule != "" {
+				rule = fmt.Sprintf("%s/%s", failure.Check, failure.Rule)
+			}
+			if denials[namespace] == nil {
+				denials[namespace] = map[string][]Denial{}
+			}
+			denials[namespace][rule] = append(denials[namespace][rule], Denial{Kind: kind, Name: name, Message: failure.Message()})
+		}
+	}
+
+	report := &Report{}
+	for _, netAttachDef := range objects.NetAttachDefs {
+		add(netAttachDef.GetNamespace(), kindNetAttachDef, netAttachDef.GetName(), webhook.CheckNetworkAttachmentDefinition(*netAttachDef))
+		report.Checked++
+	}
+	for _, pod := range objects.Pods {
+		add(pod.GetNamespace(), kindPod, pod.GetName(), webhook.CheckPod(*pod))
+		report.Checked++
+	}
+
+	for namespace, rules := range denials {
+		group := NamespaceGroup{Namespace: namespace}
+		for rule, ruleDenials := range rules {
+			sort.Slice(ruleDenials, func(i, j int) bool {
+				if ruleDenials[i].Kind != ruleDenials[j].Kind {
+					return ruleDenials[i].Kind < ruleDenials[j].Kind
+				}
+				return ruleDenials[i].Name < ruleDenials[j].Name
+			})
+			group.Rules = append(group.Rules, RuleGroup{Rule: rule, Denials: ruleDenials})
+		}
+		sort.Slice(group.Rules, func(i, j int) bool { return group.Rules[i].Rule < group.Rules[j].Rule })
+		report.Namespaces = append(report.Namespaces, group)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool {
+		return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace
+	})
+	return report, nil
+}
+
+// Denials returns the number of denials in the report
+func (r *Report) Denials() int {
+	count := 0
+	for _, ns := range r.Namespaces {
+		for _, rule := range ns.Rules {
+			count += len(rule.Denials)
+		}
+	}
+	return count
+}
+
+// WriteTable writes the report as a table
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tRULE\tKIND\tNAME\tMESSAGE")
+	for _, ns := range r.Namespaces {
+		for _, rule := range ns.Rules {
+			for _, denial := range rule.Denials {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", ns.Namespace, rule.Rule, denial.Kind, denial.Name, denial.Message)
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d would-be denials in %d objects\n", r.Denials(), r.Checked)
+	return err
+}
+
+// WriteJSON writes the report as JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string       `xml:"classname,attr"`
+	Name      string       `xml:"name,attr"`
+	Failure   junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+// WriteJUnit writes the report as JUnit XML, with a test suite per namespace
+// and a failed test case per denial whose class name is the rule
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{}
+	for _, ns := range r.Namespaces {
+		suite := junitTestSuite{Name: ns.Namespace}
+		for _, rule := range ns.Rules {
+			for _, denial := range rule.Denials {
+				suite.TestCases = append(suite.TestCases, junitTestCase{
+					ClassName: rule.Rule,
+					Name:      fmt.Sprintf("%s/%s", denial.Kind, denial.Name),
+					Failure:   junitFailure{Message: denial.Message, Type: rule.Rule},
+				})
+			}
+		}
+		suite.Tests = len(suite.TestCases)
+		suite.Failures = len(suite.TestCases)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
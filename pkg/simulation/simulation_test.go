@@ -0,0 +1,215 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/webhook"
+)
+
+const objectsYAML = `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: tenant
+  annotations:
+    k8s.v1.cni.cncf.io/max-networks-per-pod: "1"
+---
+apiVersion: k8s.cni.cncf.io/v1
+kind: NetworkAttachmentDefinition
+metadata:
+  name: jumbo
+  namespace: tenant
+spec:
+  config: '{"cniVersion": "0.3.1", "type": "macvlan", "mtu": 9216}'
+---
+apiVersion: k8s.cni.cncf.io/v1
+kind: NetworkAttachmentDefinition
+metadata:
+  name: host
+  namespace: tenant
+spec:
+  config: '{"cniVersion": "0.3.1", "type": "host-device", "device": "eth1"}'
+---
+apiVersion: admission.k8s.cni.cncf.io/v1alpha1
+kind: NetworkAttachmentPolicy
+metadata:
+  name: no-host-devices
+spec:
+  deniedPluginTypes: ["host-device"]
+`
+
+const podsYAML = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: two-networks
+    namespace: tenant
+    annotations:
+      k8s.v1.cni.cncf.io/networks: jumbo,host
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: other-namespace
+    namespace: tenant
+    annotations:
+      k8s.v1.cni.cncf.io/networks: infra/jumbo
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: no-networks
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: ignored
+`
+
+var _ = Describe("Simulation", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "simulation")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(dir, "objects.yaml"), []byte(objectsYAML), 0644)).To(Succeed())
+		Expect(os.MkdirAll(filepath.Join(dir, "pods"), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "pods", "pods.yml"), []byte(podsYAML), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("not an object"), 0644)).To(Succeed())
+
+		webhook.SetTuningRestrictions(webhook.TuningRestrictions{Enabled: true, MaxMTU: webhook.DefaultMaxMTU})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		webhook.SetTuningRestrictions(webhook.TuningRestrictions{MaxMTU: webhook.DefaultMaxMTU})
+		Expect(webhook.SetCaches(nil, nil, nil)).To(Succeed())
+		webhook.SetAttachmentCounter(nil)
+	})
+
+	It("should load the objects of a directory", func() {
+		objects, err := LoadDirectory(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(objects.Namespaces).To(HaveLen(1))
+		Expect(objects.NetAttachDefs).To(HaveLen(2))
+		Expect(objects.Pods).To(HaveLen(3))
+		Expect(objects.Policies).To(HaveLen(1))
+		Expect(objects.Policies[0].Spec.DeniedPluginTypes).To(ConsistOf("host-device"))
+	})
+
+	It("should fail on invalid files", func() {
+		Expect(os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("kind: [Pod"), 0644)).To(Succeed())
+		_, err := LoadDirectory(dir)
+		Expect(err).To(MatchError(ContainSubstring("broken.yaml")))
+	})
+
+	Describe("reporting", func() {
+		var report *Report
+
+		BeforeEach(func() {
+			objects, err := LoadDirectory(dir)
+			Expect(err).NotTo(HaveOccurred())
+			report, err = Run(objects, true)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should group the denials by namespace and rule", func() {
+			Expect(report.Checked).To(Equal(5))
+			Expect(report.Denials()).To(Equal(4))
+			Expect(report.Namespaces).To(HaveLen(1))
+
+			tenant := report.Namespaces[0]
+			Expect(tenant.Namespace).To(Equal("tenant"))
+			rules := []string{}
+			for _, rule := range tenant.Rules {
+				rules = append(rules, rule.Rule)
+			}
+			Expect(rules).To(Equal([]string{webhook.CheckIsolation, webhook.CheckPolicy, webhook.CheckQuota, webhook.CheckTuning}))
+			Expect(tenant.Rules[1].Denials).To(Equal([]Denial{{
+				Kind:    "NetworkAttachmentDefinition",
+				Name:    "host",
+				Message: `spec.config.type: Forbidden: CNI plugin type "host-device" is not allowed in namespace tenant`,
+			}}))
+			Expect(tenant.Rules[2].Denials[0].Name).To(Equal("two-networks"))
+		})
+
+		It("should ignore policies unless enabled", func() {
+			objects, err := LoadDirectory(dir)
+			Expect(err).NotTo(HaveOccurred())
+			report, err = Run(objects, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Denials()).To(Equal(3))
+		})
+
+		It("should count the attachments among the pods", func() {
+			objects, err := LoadDirectory(dir)
+			Expect(err).NotTo(HaveOccurred())
+			for _, netAttachDef := range objects.NetAttachDefs {
+				if netAttachDef.GetName() == "jumbo" {
+					netAttachDef.SetAnnotations(map[string]string{"k8s.v1.cni.cncf.io/max-attachments": "1"})
+				}
+			}
+			pod := objects.Pods[0].DeepCopy()
+			pod.SetName("one-network")
+			pod.SetAnnotations(map[string]string{"k8s.v1.cni.cncf.io/networks": "jumbo"})
+			objects.Pods = append(objects.Pods, pod)
+
+			report, err = Run(objects, true)
+			Expect(err).NotTo(HaveOccurred())
+			tenant := report.Namespaces[0]
+			Expect(tenant.Rules[1].Rule).To(Equal(webhook.CheckMaxAttachments))
+			Expect(tenant.Rules[1].Denials).To(HaveLen(2))
+			Expect(tenant.Rules[1].Denials[0].Message).To(HaveSuffix("net-attach-def tenant/jumbo has 1 attachments, the limit is 1"))
+		})
+
+		It("should write a table", func() {
+			var out bytes.Buffer
+			Expect(report.WriteTable(&out)).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("NAMESPACE  RULE"))
+			Expect(out.String()).To(MatchRegexp(`tenant\s+tuning\s+NetworkAttachmentDefinition\s+jumbo\s+spec.config.mtu: Invalid value: 9216`))
+			Expect(out.String()).To(HaveSuffix("4 would-be denials in 5 objects\n"))
+		})
+
+		It("should write JSON", func() {
+			var out bytes.Buffer
+			Expect(report.WriteJSON(&out)).To(Succeed())
+			decoded := &Report{}
+			Expect(json.Unmarshal(out.Bytes(), decoded)).To(Succeed())
+			Expect(decoded).To(Equal(report))
+		})
+
+		It("should write JUnit XML", func() {
+			var out bytes.Buffer
+			Expect(report.WriteJUnit(&out)).To(Succeed())
+			decoded := junitTestSuites{}
+			Expect(xml.Unmarshal(out.Bytes(), &decoded)).To(Succeed())
+			Expect(decoded.Failures).To(Equal(4))
+			Expect(decoded.Suites).To(HaveLen(1))
+			Expect(decoded.Suites[0].Name).To(Equal("tenant"))
+			Expect(decoded.Suites[0].TestCases[0].ClassName).To(Equal(webhook.CheckIsolation))
+			Expect(decoded.Suites[0].TestCases[0].Name).To(Equal("Pod/other-namespace"))
+		})
+	})
+})
@@ -0,0 +1,142 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+)
+
+// The handlers work on admissionv1 AdmissionReviews. v1beta1 reviews are converted
+// on the way in and back on the way out, their TypeMeta is kept so that the
+// response uses the version of the request.
+
+var admissionCodecs = func() serializer.CodecFactory {
+	scheme := runtime.NewScheme()
+	if err := admissionv1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	if err := admissionv1beta1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+	return serializer.NewCodecFactory(scheme)
+}()
+
+func deserializeAdmissionReview(body []byte) (*admissionv1.AdmissionReview, error) {
+	obj, gvk, err := admissionCodecs.UniversalDeserializer().Decode(body, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	switch review := obj.(type) {
+	case *admissionv1.AdmissionReview:
+		return review, nil
+	case *admissionv1beta1.AdmissionReview:
+		return v1beta1ToV1AdmissionReview(review), nil
+	}
+	return nil, errors.Errorf("received object is not an AdmissionReview: %s", gvk)
+}
+
+// isV1beta1 checks whether the review was received as v1beta1
+func isV1beta1(ar *admissionv1.AdmissionReview) bool {
+	return ar.APIVersion == admissionv1beta1.SchemeGroupVersion.String()
+}
+
+// reviewForVersion returns the review in the version it was received in
+func reviewForVersion(ar *admissionv1.AdmissionReview) interface{} {
+	if isV1beta1(ar) {
+		return v1ToV1beta1AdmissionReview(ar)
+	}
+	return ar
+}
+
+func v1beta1ToV1AdmissionReview(in *admissionv1beta1.AdmissionReview) *admissionv1.AdmissionReview {
+	out := &admissionv1.AdmissionReview{TypeMeta: in.TypeMeta}
+	if in.Request != nil {
+		out.Request = &admissionv1.AdmissionRequest{
+			UID:                in.Request.UID,
+			Kind:               in.Request.Kind,
+			Resource:           in.Request.Resource,
+			SubResource:        in.Request.SubResource,
+			RequestKind:        in.Request.RequestKind,
+			RequestResource:    in.Request.RequestResource,
+			RequestSubResource: in.Request.RequestSubResource,
+			Name:               in.Request.Name,
+			Namespace:          in.Request.Namespace,
+			Operation:          admissionv1.Operation(in.Request.Operation),
+			UserInfo:           in.Request.UserInfo,
+			Object:             in.Request.Object,
+			OldObject:          in.Request.OldObject,
+			DryRun:             in.Request.DryRun,
+			Options:            in.Request.Options,
+		}
+	}
+	if in.Response != nil {
+		out.Response = &admissionv1.AdmissionResponse{
+			UID:              in.Response.UID,
+			Allowed:          in.Response.Allowed,
+			Result:           in.Response.Result,
+			Patch:            in.Response.Patch,
+			AuditAnnotations: in.Response.AuditAnnotations,
+			Warnings:         in.Response.Warnings,
+		}
+		if in.Response.PatchType != nil {
+			patchType := admissionv1.PatchType(*in.Response.PatchType)
+			out.Response.PatchType = &patchType
+		}
+	}
+	return out
+}
+
+func v1ToV1beta1AdmissionReview(in *admissionv1.AdmissionReview) *admissionv1beta1.AdmissionReview {
+	out := &admissionv1beta1.AdmissionReview{TypeMeta: in.TypeMeta}
+	if in.Request != nil {
+		out.Request = &admissionv1beta1.AdmissionRequest{
+			UID:                in.Request.UID,
+			Kind:               in.Request.Kind,
+			Resource:           in.Request.Resource,
+			SubResource:        in.Request.SubResource,
+			RequestKind:        in.Request.RequestKind,
+			RequestResource:    in.Request.RequestResource,
+			RequestSubResource: in.Request.RequestSubResource,
+			Name:               in.Request.Name,
+			Namespace:          in.Request.Namespace,
+			Operation:          admissionv1beta1.Operation(in.Request.Operation),
+			UserInfo:           in.Request.UserInfo,
+			Object:             in.Request.Object,
+			OldObject:          in.Request.OldObject,
+			DryRun:             in.Request.DryRun,
+			Options:            in.Request.Options,
+		}
+	}
+	if in.Response != nil {
+		out.Response = &admissionv1beta1.AdmissionResponse{
+			UID:              in.Response.UID,
+			Allowed:          in.Response.Allowed,
+			Result:           in.Response.Result,
+			Patch:            in.Response.Patch,
+			AuditAnnotations: in.Response.AuditAnnotations,
+			Warnings:         in.Response.Warnings,
+		}
+		if in.Response.PatchType != nil {
+			patchType := admissionv1beta1.PatchType(*in.Response.PatchType)
+			out.Response.PatchType = &patchType
+		}
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reviewBody returns a CREATE AdmissionReview of the object in the given version
+func reviewBody(apiVersion string, obj interface{}) []byte {
+	raw, err := json.Marshal(obj)
+	Expect(err).NotTo(HaveOccurred())
+	return []byte(fmt.Sprintf(`{
+		"apiVersion": %q,
+		"kind": "AdmissionReview",
+		"request": {
+			"uid": "5678",
+			"kind": {"group": "k8s.cni.cncf.io", "version": "v1", "kind": "NetworkAttachmentDefinition"},
+			"resource": {"group": "k8s.cni.cncf.io", "version": "v1", "resource": "network-attachment-definitions"},
+			"namespace": "tenant",
+			"operation": "CREATE",
+			"userInfo": {"username": "admin"},
+			"object": %s
+		}
+	}`, apiVersion, raw))
+}
+
+// wireResponse is the part of an AdmissionReview response common to all versions
+type wireResponse struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Response   struct {
+		UID      string         `json:"uid"`
+		Allowed  bool           `json:"allowed"`
+		Result   *metav1.Status `json:"status"`
+		Warnings []string       `json:"warnings"`
+	} `json:"response"`
+}
+
+var _ = Describe("AdmissionReview versions", func() {
+	DescribeTable("replying in the version of the request",
+		func(apiVersion string) {
+			netAttachDef := netv1.NetworkAttachmentDefinition{
+				ObjectMeta: metav1.ObjectMeta{Name: "some?invalid?name"},
+				Spec:       netv1.NetworkAttachmentDefinitionSpec{Config: `{"cniVersion": "0.3.1", "type": "bridge"}`},
+			}
+			req := httptest.NewRequest("POST", "https://fakewebhook/validate", bytes.NewBuffer(reviewBody(apiVersion, netAttachDef)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			ValidateHandler(w, req)
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			resp := wireResponse{}
+			Expect(json.Unmarshal(w.Body.Bytes(), &resp)).To(Succeed())
+			Expect(resp.APIVersion).To(Equal(apiVersion))
+			Expect(resp.Kind).To(Equal("AdmissionReview"))
+			Expect(resp.Response.UID).To(Equal("5678"))
+			Expect(resp.Response.Allowed).To(BeFalse())
+			Expect(resp.Response.Result.Reason).To(Equal(metav1.StatusReasonInvalid))
+			Expect(resp.Response.Result.Details.Kind).To(Equal("NetworkAttachmentDefinition"))
+			Expect(resp.Response.Result.Details.Causes).To(ContainElement(HaveField("Field", "metadata.name")))
+		},
+		Entry("v1", "admission.k8s.io/v1"),
+		Entry("v1beta1", "admission.k8s.io/v1beta1"),
+	)
+
+	DescribeTable("converting requests",
+		func(apiVersion string) {
+			ar, err := deserializeAdmissionReview(reviewBody(apiVersion, newPod(nil)))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ar.APIVersion).To(Equal(apiVersion))
+			Expect(string(ar.Request.UID)).To(Equal("5678"))
+			Expect(string(ar.Request.Operation)).To(Equal("CREATE"))
+			Expect(ar.Request.Namespace).To(Equal("tenant"))
+			Expect(ar.Request.Kind.Kind).To(Equal("NetworkAttachmentDefinition"))
+			Expect(ar.Request.UserInfo.Username).To(Equal("admin"))
+			Expect(string(ar.Request.Object.Raw)).To(ContainSubstring(`"name":"some-pod"`))
+		},
+		Entry("v1", "admission.k8s.io/v1"),
+		Entry("v1beta1", "admission.k8s.io/v1beta1"),
+	)
+
+	It("should reject unknown versions", func() {
+		_, err := deserializeAdmissionReview(reviewBody("admission.k8s.io/v2", newPod(nil)))
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,255 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// maxAttachmentsAnnotationKey is the net-attach-def annotation limiting the pods attached to it
+	maxAttachmentsAnnotationKey = "k8s.v1.cni.cncf.io/max-attachments"
+
+	// attachmentReservationTTL is how long admitted pods are counted as attached
+	// while the attachment counter has not seen them yet
+	attachmentReservationTTL = 30 * time.Second
+)
+
+// AttachmentCounter lists the pods attached to a net-attach-def
+type AttachmentCounter interface {
+	// AttachedPods returns the namespace/name keys of the pods attached to the
+	// net-attach-def that have not terminated
+	AttachedPods(namespace, name string) []string
+}
+
+var (
+	attachmentCounter AttachmentCounter
+
+	// attachmentLocks serialize the admission of the pods attached to each
+	// limited net-attach-def, so that each admitted pod is counted by the next one
+	attachmentLocks      = map[string]*sync.Mutex{}
+	attachmentLocksMutex sync.Mutex
+	// reservedAttachments are the expiry times of the admitted pods by
+	// net-attach-def key and pod key
+	reservedAttachments      = map[string]map[string]time.Time{}
+	reservedAttachmentsMutex sync.Mutex
+)
+
+// SetAttachmentCounter sets the source of the attachment counts used to enforce
+// the max-attachments annotation of net-attach-defs
+func SetAttachmentCounter(counter AttachmentCounter) {
+	attachmentCounter = counter
+}
+
+// podAttachmentCounter counts the pods of a list
+type podAttachmentCounter map[string][]string
+
+func (c podAttachmentCounter) AttachedPods(namespace, name string) []string {
+	return c[namespace+"/"+name]
+}
+
+// NewPodAttachmentCounter returns an attachment counter of the pods that have
+// not terminated in the list
+func NewPodAttachmentCounter(pods []*v1.Pod) AttachmentCounter {
+	counter := podAttachmentCounter{}
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		annotation := pod.GetAnnotations()[networksAnnotationKey]
+		if annotation == "" {
+			continue
+		}
+		networks, err := parsePodNetworkAnnotation(annotation, pod.GetNamespace())
+		if err != nil {
+			continue
+		}
+		checked := map[string]struct{}{}
+		for _, network := range networks {
+			key := network.Namespace + "/" + network.Name
+			if _, found := checked[key]; found {
+				continue
+			}
+			checked[key] = struct{}{}
+			counter[key] = append(counter[key], pod.GetNamespace()+"/"+pod.GetName())
+		}
+	}
+	return counter
+}
+
+// getNetAttachDef returns the cached net-attach-def, nil if unknown
+func getNetAttachDef(namespace, name string) *netv1.NetworkAttachmentDefinition {
+	if netAttachDefIndexer == nil {
+		return nil
+	}
+	obj, exists, err := netAttachDefIndexer.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil
+	}
+	return obj.(*netv1.NetworkAttachmentDefinition)
+}
+
+// maxAttachments returns the limit set by the net-attach-def annotation, zero if unlimited
+func maxAttachments(netAttachDef *netv1.NetworkAttachmentDefinition) int {
+	value, ok := netAttachDef.GetAnnotations()[maxAttachmentsAnnotationKey]
+	if !ok {
+		return 0
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		glog.Errorf("ignoring invalid %s annotation %q of net-attach-def %s/%s", maxAttachmentsAnnotationKey, value, netAttachDef.GetNamespace(), netAttachDef.GetName())
+		return 0
+	}
+	return limit
+}
+
+// limitedNetwork is a net-attach-def with a maximum number of attachments
+type limitedNetwork struct {
+	key   string
+	limit int
+}
+
+// limitedNetworks returns the net-attach-defs with a maximum number of
+// attachments requested by the pod
+func limitedNetworks(pod v1.Pod, namespace string) []limitedNetwork {
+	annotation := pod.GetAnnotations()[networksAnnotationKey]
+	if attachmentCounter == nil || annotation == "" {
+		return nil
+	}
+
+	// malformed annotations are denied by validatePodIsolation
+	networks, err := parsePodNetworkAnnotation(annotation, namespace)
+	if err != nil {
+		return nil
+	}
+
+	var limited []limitedNetwork
+	checked := map[string]struct{}{}
+	for _, network := range networks {
+		key := network.Namespace + "/" + network.Name
+		if _, found := checked[key]; found {
+			continue
+		}
+		checked[key] = struct{}{}
+
+		netAttachDef := getNetAttachDef(network.Namespace, network.Name)
+		if netAttachDef == nil {
+			continue
+		}
+		if limit := maxAttachments(netAttachDef); limit > 0 {
+			limited = append(limited, limitedNetwork{key: key, limit: limit})
+		}
+	}
+	return limited
+}
+
+// lockAttachments locks the net-attach-defs in key order, so that pods
+// attached to several of them do not deadlock, and returns the function
+// unlocking them
+func lockAttachments(networks []limitedNetwork) func() {
+	keys := make([]string, 0, len(networks))
+	for _, network := range networks {
+		keys = append(keys, network.key)
+	}
+	sort.Strings(keys)
+
+	locks := make([]*sync.Mutex, 0, len(keys))
+	attachmentLocksMutex.Lock()
+	for _, key := range keys {
+		lock, found := attachmentLocks[key]
+		if !found {
+			lock = &sync.Mutex{}
+			attachmentLocks[key] = lock
+		}
+		locks = append(locks, lock)
+	}
+	attachmentLocksMutex.Unlock()
+
+	for _, lock := range locks {
+		lock.Lock()
+	}
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+		}
+	}
+}
+
+// attachmentCount returns the number of pods other than podKey attached to the
+// net-attach-def, counting the admitted pods the attachment counter has not
+// seen yet
+func attachmentCount(network, podKey string) int {
+	namespace, name, _ := cache.SplitMetaNamespaceKey(network)
+	attached := map[string]struct{}{}
+	for _, key := range attachmentCounter.AttachedPods(namespace, name) {
+		attached[key] = struct{}{}
+	}
+
+	reservedAttachmentsMutex.Lock()
+	defer reservedAttachmentsMutex.Unlock()
+	current := now()
+	for key, expiry := range reservedAttachments[network] {
+		if _, seen := attached[key]; seen || current.After(expiry) {
+			delete(reservedAttachments[network], key)
+			continue
+		}
+		attached[key] = struct{}{}
+	}
+	if len(reservedAttachments[network]) == 0 {
+		delete(reservedAttachments, network)
+	}
+	delete(attached, podKey)
+	return len(attached)
+}
+
+// reserveAttachments counts the admitted pod as attached to the net-attach-defs
+// until the attachment counter sees it
+func reserveAttachments(podKey string, networks []limitedNetwork) {
+	reservedAttachmentsMutex.Lock()
+	defer reservedAttachmentsMutex.Unlock()
+	expiry := now().Add(attachmentReservationTTL)
+	for _, network := range networks {
+		if reservedAttachments[network.key] == nil {
+			reservedAttachments[network.key] = map[string]time.Time{}
+		}
+		reservedAttachments[network.key][podKey] = expiry
+	}
+}
+
+// validateMaxAttachments checks that none of the net-attach-defs requested by the pod
+// has reached its maximum number of attachments, not counting the pod itself
+func validateMaxAttachments(pod v1.Pod, namespace string) (bool, error) {
+	var allErrs field.ErrorList
+	podKey := namespace + "/" + pod.GetName()
+	for _, network := range limitedNetworks(pod, namespace) {
+		if count := attachmentCount(network.key, podKey); count >= network.limit {
+			allErrs = append(allErrs, field.Forbidden(annotationsPath.Key(networksAnnotationKey), fmt.Sprintf("net-attach-def %s has %d attachments, the limit is %d", network.key, count, network.limit)))
+		}
+	}
+	if len(allErrs) > 0 {
+		return false, allErrs.ToAggregate()
+	}
+	return true, nil
+}
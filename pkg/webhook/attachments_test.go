@@ -0,0 +1,176 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"fmt"
+	"time"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeAttachmentCounter returns counts keyed by namespace/name
+type fakeAttachmentCounter map[string]int
+
+func (f fakeAttachmentCounter) AttachedPods(namespace, name string) []string {
+	var keys []string
+	for i := 0; i < f[namespace+"/"+name]; i++ {
+		keys = append(keys, fmt.Sprintf("%s/attached-%d", namespace, i))
+	}
+	return keys
+}
+
+func newLimitedNetAttachDef(namespace, name, limit string) *netv1.NetworkAttachmentDefinition {
+	netAttachDef := &netv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if limit != "" {
+		netAttachDef.Annotations = map[string]string{maxAttachmentsAnnotationKey: limit}
+	}
+	return netAttachDef
+}
+
+var _ = Describe("Maximum attachments", func() {
+	BeforeEach(func() {
+		setNetAttachDefs(
+			newLimitedNetAttachDef("tenant", "static-range", "3"),
+			newLimitedNetAttachDef("tenant", "unlimited", ""),
+			newLimitedNetAttachDef("tenant", "broken", "few"),
+			newLimitedNetAttachDef("infra", "vf-pool", "2"),
+		)
+		SetAttachmentCounter(fakeAttachmentCounter{
+			"tenant/static-range": 2,
+			"tenant/unlimited":    100,
+			"tenant/broken":       100,
+			"infra/vf-pool":       2,
+		})
+	})
+
+	AfterEach(func() {
+		SetAttachmentCounter(nil)
+		netAttachDefIndexer = nil
+		now = time.Now
+		reservedAttachments = map[string]map[string]time.Time{}
+	})
+
+	DescribeTable("pod admission",
+		func(networks string, out bool) {
+			allowed, err := validateMaxAttachments(newPod(map[string]string{networksAnnotationKey: networks}), "tenant")
+			Expect(allowed).To(Equal(out))
+			if !out {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("below the limit", "static-range", true),
+		Entry("same network requested twice below the limit", "static-range,static-range@net2", true),
+		Entry("without a limit", "unlimited", true),
+		Entry("with an invalid limit", "broken", true),
+		Entry("unknown network", "missing", true),
+		Entry("at the limit of a network in another namespace", "static-range,infra/vf-pool", false),
+	)
+
+	It("should state the count and the limit", func() {
+		_, err := validateMaxAttachments(newPod(map[string]string{networksAnnotationKey: "infra/vf-pool"}), "tenant")
+		Expect(err).To(MatchError("metadata.annotations[k8s.v1.cni.cncf.io/networks]: Forbidden: net-attach-def infra/vf-pool has 2 attachments, the limit is 2"))
+	})
+
+	It("should not count the pod itself", func() {
+		pod := newPod(map[string]string{networksAnnotationKey: "infra/vf-pool"})
+		pod.Name = "attached-1"
+		allowed, err := validateMaxAttachments(pod, "infra")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeTrue())
+	})
+
+	It("should count the admitted pods the counter has not seen yet", func() {
+		current := time.Now()
+		now = func() time.Time { return current }
+		// none of the pods of the burst are seen by the counter
+		SetAttachmentCounter(fakeAttachmentCounter{})
+		admit := func(name string) bool {
+			pod := newPod(map[string]string{networksAnnotationKey: "static-range"})
+			pod.Name = name
+			return postAdmissionReview(IsolateHandler, "tenant", admissionv1.Create, pod).Allowed
+		}
+		for i := 0; i < 3; i++ {
+			Expect(admit(fmt.Sprintf("replica-%d", i))).To(BeTrue())
+		}
+		Expect(admit("replica-3")).To(BeFalse())
+
+		// the reserved pods are counted once seen by the counter
+		SetAttachmentCounter(fakeAttachmentCounter{"tenant/static-range": 1})
+		Expect(attachmentCount("tenant/static-range", "")).To(Equal(4))
+
+		// and no longer reserved once expired
+		current = current.Add(attachmentReservationTTL + time.Second)
+		Expect(admit("replica-4")).To(BeTrue())
+		Expect(attachmentCount("tenant/static-range", "")).To(Equal(2))
+	})
+
+	It("should only serialize the admissions attached to the same net-attach-def", func() {
+		unlock := lockAttachments([]limitedNetwork{{key: "tenant/static-range", limit: 3}})
+		defer func() { unlock() }()
+
+		otherLocked := make(chan struct{})
+		go func() {
+			lockAttachments([]limitedNetwork{{key: "infra/vf-pool", limit: 2}})()
+			close(otherLocked)
+		}()
+		Eventually(otherLocked).Should(BeClosed())
+
+		sameLocked := make(chan struct{})
+		go func() {
+			lockAttachments([]limitedNetwork{{key: "infra/vf-pool", limit: 2}, {key: "tenant/static-range", limit: 3}})()
+			close(sameLocked)
+		}()
+		Consistently(sameLocked, 200*time.Millisecond).ShouldNot(BeClosed())
+		unlock()
+		unlock = func() {}
+		Eventually(sameLocked).Should(BeClosed())
+	})
+
+	It("should count the pending pods of a list", func() {
+		pending := newPod(map[string]string{networksAnnotationKey: "static-range"})
+		pending.Namespace = "tenant"
+		pending.Status.Phase = v1.PodPending
+		succeeded := pending.DeepCopy()
+		succeeded.Name = "done"
+		succeeded.Status.Phase = v1.PodSucceeded
+		counter := NewPodAttachmentCounter([]*v1.Pod{&pending, succeeded})
+		Expect(counter.AttachedPods("tenant", "static-range")).To(ConsistOf("tenant/some-pod"))
+	})
+
+	Context("without an attachment counter", func() {
+		BeforeEach(func() {
+			SetAttachmentCounter(nil)
+		})
+
+		It("should allow the pod", func() {
+			allowed, err := validateMaxAttachments(newPod(map[string]string{networksAnnotationKey: "infra/vf-pool"}), "tenant")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		})
+	})
+})
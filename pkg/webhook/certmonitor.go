@@ -0,0 +1,169 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+)
+
+// DefaultCertExpiryWarningThresholds are how long before a served certificate
+// expires a warning is logged, once per threshold crossed
+var DefaultCertExpiryWarningThresholds = []time.Duration{30 * 24 * time.Hour, 7 * 24 * time.Hour, 24 * time.Hour}
+
+var (
+	certExpiryWarningThresholds = DefaultCertExpiryWarningThresholds
+	// certExpiryCheckInterval is how often the served certificates are checked
+	// for expiry between reloads
+	certExpiryCheckInterval = time.Hour
+
+	servedCertificatesMutex sync.Mutex
+	// servedCertificates are the leaf certificates served, by the file or
+	// secret they are loaded from
+	servedCertificates = map[string]*x509.Certificate{}
+	// certExpiryWarned is the smallest threshold warned about for the
+	// certificate served from each file or secret
+	certExpiryWarned = map[string]certExpiryWarning{}
+)
+
+type certExpiryWarning struct {
+	serial    string
+	threshold time.Duration
+}
+
+// SetCertExpiryWarningThresholds sets how long before a served certificate
+// expires warnings are logged
+func SetCertExpiryWarningThresholds(thresholds []time.Duration) {
+	servedCertificatesMutex.Lock()
+	defer servedCertificatesMutex.Unlock()
+	certExpiryWarningThresholds = append([]time.Duration{}, thresholds...)
+	sort.Slice(certExpiryWarningThresholds, func(i, j int) bool {
+		return certExpiryWarningThresholds[i] > certExpiryWarningThresholds[j]
+	})
+}
+
+// certificateSerial formats the serial number of a certificate as openssl does
+func certificateSerial(cert *x509.Certificate) string {
+	return fmt.Sprintf("%X", cert.SerialNumber)
+}
+
+// recordServedCertificate updates the metrics of the certificate now served
+// from source, and warns if it expires soon
+func recordServedCertificate(source string, cert *tls.Certificate) {
+	leaf := cert.Leaf
+	if leaf == nil {
+		var err error
+		if leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			glog.Errorf("error parsing the certificate served from %s: %v", source, err)
+			return
+		}
+	}
+	localmetrics.SetCertificateValidity(source, leaf.NotBefore, leaf.NotAfter, certificateSerial(leaf))
+
+	servedCertificatesMutex.Lock()
+	defer servedCertificatesMutex.Unlock()
+	servedCertificates[source] = leaf
+	warnCertificateExpiry(source, leaf)
+}
+
+// warnCertificateExpiry logs a warning when the certificate crosses one of the
+// thresholds, or an error when it expired. servedCertificatesMutex is held.
+func warnCertificateExpiry(source string, leaf *x509.Certificate) {
+	remaining := leaf.NotAfter.Sub(now())
+	// the thresholds are sorted in decreasing order, the last one crossed is
+	// the one to warn about
+	threshold := time.Duration(-1)
+	for _, t := range certExpiryWarningThresholds {
+		if remaining <= t {
+			threshold = t
+		}
+	}
+	if remaining <= 0 {
+		threshold = 0
+	}
+	if threshold < 0 {
+		return
+	}
+
+	serial := certificateSerial(leaf)
+	if warned, ok := certExpiryWarned[source]; ok && warned.serial == serial && warned.threshold <= threshold {
+		return
+	}
+	certExpiryWarned[source] = certExpiryWarning{serial: serial, threshold: threshold}
+	if threshold == 0 {
+		glog.Errorf("the certificate served from %s (serial %s) expired on %v", source, serial, leaf.NotAfter)
+		return
+	}
+	glog.Warningf("the certificate served from %s (serial %s) expires in less than %v, on %v", source, serial, threshold, leaf.NotAfter)
+}
+
+// checkCertificateExpiry warns about the served certificates expiring soon
+func checkCertificateExpiry() {
+	servedCertificatesMutex.Lock()
+	defer servedCertificatesMutex.Unlock()
+	for source, leaf := range servedCertificates {
+		warnCertificateExpiry(source, leaf)
+	}
+}
+
+// StartCertificateExpiryMonitor periodically warns about the served
+// certificates expiring soon, until stopCh is closed
+func StartCertificateExpiryMonitor(stopCh <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(certExpiryCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				checkCertificateExpiry()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// CheckServedCertificates returns an error if no certificate is served, or if
+// a served certificate is not valid now or is not valid for dnsName. The name
+// is not checked if it is empty.
+func CheckServedCertificates(dnsName string) error {
+	servedCertificatesMutex.Lock()
+	defer servedCertificatesMutex.Unlock()
+	if len(servedCertificates) == 0 {
+		return fmt.Errorf("no certificate loaded")
+	}
+	current := now()
+	for source, leaf := range servedCertificates {
+		if current.After(leaf.NotAfter) {
+			return fmt.Errorf("the certificate served from %s expired on %v", source, leaf.NotAfter)
+		}
+		if current.Before(leaf.NotBefore) {
+			return fmt.Errorf("the certificate served from %s is not valid before %v", source, leaf.NotBefore)
+		}
+		if dnsName != "" {
+			if err := leaf.VerifyHostname(dnsName); err != nil {
+				return fmt.Errorf("the certificate served from %s is not valid for %s: %v", source, dnsName, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// certificateGauge returns the value of a certificate gauge
+func certificateGauge(labelValues ...string) float64 {
+	metric := &dto.Metric{}
+	gauge := localmetrics.CertificateNotAfter
+	if len(labelValues) == 2 {
+		gauge = localmetrics.CertificateInfo
+	}
+	Expect(gauge.WithLabelValues(labelValues...).Write(metric)).To(Succeed())
+	return metric.GetGauge().GetValue()
+}
+
+var _ = Describe("Certificate expiry monitor", func() {
+	var current time.Time
+
+	// newServedCertificate returns a certificate valid for the service name
+	// until notAfter
+	newServedCertificate := func(notAfter time.Time) *tls.Certificate {
+		pair, err := generateCertificate(&x509.Certificate{
+			Subject:     pkix.Name{CommonName: "webhook"},
+			DNSNames:    serviceDNSNames("kube-system", "webhook"),
+			NotBefore:   notAfter.Add(-365 * 24 * time.Hour),
+			NotAfter:    notAfter,
+			KeyUsage:    x509.KeyUsageDigitalSignature,
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, nil)
+		Expect(err).NotTo(HaveOccurred())
+		cert, err := parseKeyPair(pair)
+		Expect(err).NotTo(HaveOccurred())
+		return cert
+	}
+
+	warnedThreshold := func(source string) time.Duration {
+		servedCertificatesMutex.Lock()
+		defer servedCertificatesMutex.Unlock()
+		warned, ok := certExpiryWarned[source]
+		if !ok {
+			return -1
+		}
+		return warned.threshold
+	}
+
+	BeforeEach(func() {
+		current = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+		now = func() time.Time { return current }
+		servedCertificates = map[string]*x509.Certificate{}
+		certExpiryWarned = map[string]certExpiryWarning{}
+		SetCertExpiryWarningThresholds([]time.Duration{24 * time.Hour, 7 * 24 * time.Hour})
+	})
+
+	AfterEach(func() {
+		now = time.Now
+		servedCertificates = map[string]*x509.Certificate{}
+		certExpiryWarned = map[string]certExpiryWarning{}
+		SetCertExpiryWarningThresholds(DefaultCertExpiryWarningThresholds)
+	})
+
+	It("should expose the validity and serial of the served certificate", func() {
+		cert := newServedCertificate(current.Add(30 * 24 * time.Hour))
+		recordServedCertificate("/etc/webhook/cert.pem", cert)
+		Expect(certificateGauge("/etc/webhook/cert.pem")).To(Equal(float64(cert.Leaf.NotAfter.Unix())))
+		Expect(certificateGauge("/etc/webhook/cert.pem", certificateSerial(cert.Leaf))).To(Equal(1.0))
+
+		renewed := newServedCertificate(current.Add(60 * 24 * time.Hour))
+		recordServedCertificate("/etc/webhook/cert.pem", renewed)
+		Expect(certificateGauge("/etc/webhook/cert.pem")).To(Equal(float64(renewed.Leaf.NotAfter.Unix())))
+		Expect(localmetrics.CertificateInfo.DeleteLabelValues("/etc/webhook/cert.pem", certificateSerial(cert.Leaf))).To(BeFalse())
+	})
+
+	It("should warn once per threshold crossed", func() {
+		cert := newServedCertificate(current.Add(10 * 24 * time.Hour))
+		recordServedCertificate("cert.pem", cert)
+		Expect(warnedThreshold("cert.pem")).To(Equal(time.Duration(-1)))
+
+		current = current.Add(5 * 24 * time.Hour)
+		checkCertificateExpiry()
+		Expect(warnedThreshold("cert.pem")).To(Equal(7 * 24 * time.Hour))
+
+		current = current.Add(4*24*time.Hour + time.Hour)
+		checkCertificateExpiry()
+		Expect(warnedThreshold("cert.pem")).To(Equal(24 * time.Hour))
+
+		current = current.Add(24 * time.Hour)
+		checkCertificateExpiry()
+		Expect(warnedThreshold("cert.pem")).To(Equal(time.Duration(0)))
+
+		// a renewed certificate is warned about again
+		recordServedCertificate("cert.pem", newServedCertificate(current.Add(12*time.Hour)))
+		Expect(warnedThreshold("cert.pem")).To(Equal(24 * time.Hour))
+	})
+
+	It("should only be ready with valid certificates for the service name", func() {
+		Expect(CheckServedCertificates("webhook.kube-system.svc")).To(MatchError("no certificate loaded"))
+
+		recordServedCertificate("cert.pem", newServedCertificate(current.Add(24*time.Hour)))
+		Expect(CheckServedCertificates("webhook.kube-system.svc")).To(Succeed())
+		Expect(CheckServedCertificates("")).To(Succeed())
+		Expect(CheckServedCertificates("other.kube-system.svc")).To(MatchError(ContainSubstring("is not valid for other.kube-system.svc")))
+
+		current = current.Add(25 * time.Hour)
+		Expect(CheckServedCertificates("webhook.kube-system.svc")).To(MatchError(ContainSubstring("expired on")))
+	})
+})
@@ -0,0 +1,436 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/golang/glog"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// DefaultCAValidity is the lifetime of a generated CA
+	DefaultCAValidity = 5 * 365 * 24 * time.Hour
+	// DefaultCertValidity is the lifetime of a generated serving certificate
+	DefaultCertValidity = 365 * 24 * time.Hour
+	// DefaultCertRotateBefore is how long before they expire the CA and the
+	// serving certificate are replaced
+	DefaultCertRotateBefore = 30 * 24 * time.Hour
+
+	// CACertKey is the key of the CA bundle in the generated secret
+	CACertKey = "ca.crt"
+	// caPrivateKeyKey is the key of the private key of the current CA
+	caPrivateKeyKey = "ca.key"
+)
+
+// certRotationCheckInterval is how often the certificates are checked, it is
+// also the delay before a failed rotation is retried
+var certRotationCheckInterval = 10 * time.Minute
+
+// CertRotationConfig configures the certificates generated by the webhook
+type CertRotationConfig struct {
+	// SecretNamespace and SecretName name the secret holding the CA and the
+	// serving certificate
+	SecretNamespace string
+	SecretName      string
+	// ServiceNamespace and ServiceName name the service of the webhook, the
+	// serving certificate is valid for its DNS names and the webhook
+	// configurations calling it trust the CA
+	ServiceNamespace string
+	ServiceName      string
+	CAValidity       time.Duration
+	CertValidity     time.Duration
+	RotateBefore     time.Duration
+}
+
+// Validate checks that the certificates are valid longer than they are kept,
+// otherwise they would be replaced on every check
+func (config *CertRotationConfig) Validate() error {
+	if config.CAValidity <= config.RotateBefore {
+		return fmt.Errorf("the CA validity %v must be longer than the rotation delay %v", config.CAValidity, config.RotateBefore)
+	}
+	if config.CertValidity <= config.RotateBefore {
+		return fmt.Errorf("the serving certificate validity %v must be longer than the rotation delay %v", config.CertValidity, config.RotateBefore)
+	}
+	return nil
+}
+
+// keyPairPEM is a PEM encoded certificate and its key
+type keyPairPEM struct {
+	cert []byte
+	key  []byte
+}
+
+// serviceDNSNames returns the names the API server may use to reach the service
+func serviceDNSNames(namespace, name string) []string {
+	return []string{
+		name,
+		fmt.Sprintf("%s.%s", name, namespace),
+		fmt.Sprintf("%s.%s.svc", name, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace),
+	}
+}
+
+// generateCertificate creates an ECDSA key and a certificate for it, signed by
+// the parent, or self-signed if parent is nil
+func generateCertificate(template *x509.Certificate, parent *tls.Certificate) (keyPairPEM, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return keyPairPEM{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return keyPairPEM{}, err
+	}
+	template.SerialNumber = serial
+
+	parentCert, parentKey := template, interface{}(key)
+	if parent != nil {
+		parentCert, parentKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		return keyPairPEM{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return keyPairPEM{}, err
+	}
+	return keyPairPEM{
+		cert: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}, nil
+}
+
+// parseKeyPair parses a PEM encoded key pair and its leaf certificate
+func parseKeyPair(pair keyPairPEM) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(pair.cert, pair.key)
+	if err != nil {
+		return nil, err
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// parseCertificates parses the certificates of a PEM bundle, skipping the
+// blocks that are not certificates
+func parseCertificates(bundle []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// expiresWithin tells whether the certificate is not valid at any time until d from now
+func expiresWithin(cert *x509.Certificate, d time.Duration) bool {
+	t := now()
+	return t.Before(cert.NotBefore) || t.Add(d).After(cert.NotAfter)
+}
+
+// rotateCA returns the current CA, its PEM encoded key and the CA bundle,
+// generating a new CA if the current one expires soon. The bundle holds the
+// current CA first, followed by the previous ones until they expire, so that
+// the serving certificates they signed are trusted while they are replaced.
+func (config *CertRotationConfig) rotateCA(data map[string][]byte) (*tls.Certificate, []byte, []byte, error) {
+	previous := parseCertificates(data[CACertKey])
+	ca, err := parseKeyPair(keyPairPEM{cert: data[CACertKey], key: data[caPrivateKeyKey]})
+	if err == nil && !expiresWithin(ca.Leaf, config.RotateBefore) {
+		return ca, data[caPrivateKeyKey], caBundle(ca.Leaf, previous[1:]), nil
+	}
+
+	t := now()
+	newCA, err := generateCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: fmt.Sprintf("%s-ca@%d", config.ServiceName, t.Unix())},
+		NotBefore:             t.Add(-time.Hour),
+		NotAfter:              t.Add(config.CAValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error generating CA: %v", err)
+	}
+	ca, err = parseKeyPair(newCA)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	glog.Infof("generated CA %q valid until %v", ca.Leaf.Subject.CommonName, ca.Leaf.NotAfter)
+	return ca, newCA.key, caBundle(ca.Leaf, previous), nil
+}
+
+// caBundle encodes the current CA followed by the previous CAs that have not expired
+func caBundle(current *x509.Certificate, previous []*x509.Certificate) []byte {
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: current.Raw})
+	for _, cert := range previous {
+		if now().Before(cert.NotAfter) {
+			bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+		}
+	}
+	return bundle
+}
+
+// servingCertValid tells whether the serving certificate of the secret is signed
+// by the CA, covers the service and does not expire soon
+func (config *CertRotationConfig) servingCertValid(data map[string][]byte, ca *tls.Certificate) bool {
+	cert, err := parseKeyPair(keyPairPEM{cert: data[v1.TLSCertKey], key: data[v1.TLSPrivateKeyKey]})
+	if err != nil || expiresWithin(cert.Leaf, config.RotateBefore) {
+		return false
+	}
+	if cert.Leaf.CheckSignatureFrom(ca.Leaf) != nil {
+		return false
+	}
+	for _, name := range serviceDNSNames(config.ServiceNamespace, config.ServiceName) {
+		if cert.Leaf.VerifyHostname(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// rotateServingCert returns the serving certificate, generating a new one if
+// the current one is not valid
+func (config *CertRotationConfig) rotateServingCert(data map[string][]byte, ca *tls.Certificate) (keyPairPEM, error) {
+	if config.servingCertValid(data, ca) {
+		return keyPairPEM{cert: data[v1.TLSCertKey], key: data[v1.TLSPrivateKeyKey]}, nil
+	}
+
+	t := now()
+	notAfter := t.Add(config.CertValidity)
+	if notAfter.After(ca.Leaf.NotAfter) {
+		notAfter = ca.Leaf.NotAfter
+	}
+	dnsNames := serviceDNSNames(config.ServiceNamespace, config.ServiceName)
+	cert, err := generateCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: dnsNames[2]},
+		DNSNames:    dnsNames,
+		NotBefore:   t.Add(-time.Hour),
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	if err != nil {
+		return keyPairPEM{}, fmt.Errorf("error generating serving certificate: %v", err)
+	}
+	glog.Infof("generated serving certificate for %s valid until %v", dnsNames[2], notAfter)
+	return cert, nil
+}
+
+// patchCABundles sets the CA bundle of the webhooks calling the service in the
+// validating and mutating webhook configurations
+func (config *CertRotationConfig) patchCABundles(ctx context.Context, caBundle []byte) error {
+	callsService := func(clientConfig *admissionregistrationv1.WebhookClientConfig) bool {
+		return clientConfig.Service != nil &&
+			clientConfig.Service.Namespace == config.ServiceNamespace &&
+			clientConfig.Service.Name == config.ServiceName &&
+			!bytes.Equal(clientConfig.CABundle, caBundle)
+	}
+
+	validating, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing validating webhook configurations: %v", err)
+	}
+	for i := range validating.Items {
+		configuration := &validating.Items[i]
+		changed := false
+		for j := range configuration.Webhooks {
+			if callsService(&configuration.Webhooks[j].ClientConfig) {
+				configuration.Webhooks[j].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if _, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(ctx, configuration, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating the CA bundle of validating webhook configuration %s: %v", configuration.Name, err)
+		}
+		glog.Infof("updated the CA bundle of validating webhook configuration %s", configuration.Name)
+	}
+
+	mutating, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("error listing mutating webhook configurations: %v", err)
+	}
+	for i := range mutating.Items {
+		configuration := &mutating.Items[i]
+		changed := false
+		for j := range configuration.Webhooks {
+			if callsService(&configuration.Webhooks[j].ClientConfig) {
+				configuration.Webhooks[j].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		if _, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Update(ctx, configuration, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating the CA bundle of mutating webhook configuration %s: %v", configuration.Name, err)
+		}
+		glog.Infof("updated the CA bundle of mutating webhook configuration %s", configuration.Name)
+	}
+	return nil
+}
+
+// EnsureCertificates generates the CA and the serving certificate into the
+// secret if they are missing or expire soon, and makes the webhook
+// configurations calling the service trust the CA stored in the secret. When
+// the secret is updated, the webhook configurations are updated first, so that
+// they trust a new CA before a serving certificate signed by it is served. When
+// another replica stores the certificates first, they are read again.
+func EnsureCertificates(ctx context.Context, config *CertRotationConfig) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsAlreadyExists(err) || apierrors.IsConflict(err)
+	}, func() error {
+		return config.ensureCertificates(ctx)
+	})
+}
+
+// ensureCertificates stores the certificates once, it returns the error of the
+// API server when the secret was changed by another replica
+func (config *CertRotationConfig) ensureCertificates(ctx context.Context) error {
+	secret, err := clientset.CoreV1().Secrets(config.SecretNamespace).Get(ctx, config.SecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		secret = nil
+	} else if err != nil {
+		return fmt.Errorf("error getting secret %s/%s: %v", config.SecretNamespace, config.SecretName, err)
+	}
+	var data map[string][]byte
+	if secret != nil {
+		data = secret.Data
+	}
+
+	ca, caKey, caBundle, err := config.rotateCA(data)
+	if err != nil {
+		return err
+	}
+	servingCert, err := config.rotateServingCert(data, ca)
+	if err != nil {
+		return err
+	}
+
+	newData := map[string][]byte{
+		CACertKey:           caBundle,
+		caPrivateKeyKey:     caKey,
+		v1.TLSCertKey:       servingCert.cert,
+		v1.TLSPrivateKeyKey: servingCert.key,
+	}
+
+	if secret == nil {
+		// nothing is served before the secret exists, the webhook
+		// configurations only trust the CA once it is stored
+		secret = &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: config.SecretName, Namespace: config.SecretNamespace},
+			Type:       v1.SecretTypeTLS,
+			Data:       newData,
+		}
+		if _, err := clientset.CoreV1().Secrets(config.SecretNamespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating secret %s/%s: %w", config.SecretNamespace, config.SecretName, err)
+		}
+		glog.Infof("stored the generated certificates in secret %s/%s", config.SecretNamespace, config.SecretName)
+		return config.patchCABundles(ctx, caBundle)
+	}
+
+	unchanged := len(data) == len(newData)
+	for key, value := range newData {
+		unchanged = unchanged && bytes.Equal(data[key], value)
+	}
+	if unchanged {
+		return config.patchCABundles(ctx, caBundle)
+	}
+
+	if err := config.patchCABundles(ctx, caBundle); err != nil {
+		config.restoreCABundles(ctx, data[CACertKey])
+		return err
+	}
+	secret = secret.DeepCopy()
+	secret.Data = newData
+	if _, err := clientset.CoreV1().Secrets(config.SecretNamespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		config.restoreCABundles(ctx, data[CACertKey])
+		return fmt.Errorf("error updating secret %s/%s: %w", config.SecretNamespace, config.SecretName, err)
+	}
+	glog.Infof("stored the rotated certificates in secret %s/%s", config.SecretNamespace, config.SecretName)
+	return nil
+}
+
+// restoreCABundles sets the CA bundle of the webhook configurations back to the
+// stored one, once storing a new CA failed
+func (config *CertRotationConfig) restoreCABundles(ctx context.Context, caBundle []byte) {
+	if len(parseCertificates(caBundle)) == 0 {
+		return
+	}
+	if err := config.patchCABundles(ctx, caBundle); err != nil {
+		glog.Errorf("error restoring the stored CA bundle: %v", err)
+	}
+}
+
+// StartCertRotation ensures the certificates once, then checks them
+// periodically until stopCh is closed
+func StartCertRotation(config *CertRotationConfig, stopCh <-chan struct{}) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	if err := EnsureCertificates(ctx, config); err != nil {
+		cancel()
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(certRotationCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := EnsureCertificates(ctx, config); err != nil {
+					glog.Errorf("error rotating certificates, retrying in %v: %v", certRotationCheckInterval, err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
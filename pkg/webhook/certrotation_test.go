@@ -0,0 +1,271 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Certificate rotation", func() {
+	var (
+		ctx        context.Context
+		config     *CertRotationConfig
+		fakeClient *fake.Clientset
+		oldClient  kubernetes.Interface
+		current    time.Time
+	)
+
+	webhookClientConfig := func(serviceName string) admissionregistrationv1.WebhookClientConfig {
+		return admissionregistrationv1.WebhookClientConfig{
+			Service:  &admissionregistrationv1.ServiceReference{Namespace: "kube-system", Name: serviceName},
+			CABundle: []byte("stale"),
+		}
+	}
+
+	getSecret := func() *v1.Secret {
+		secret, err := fakeClient.CoreV1().Secrets("kube-system").Get(ctx, "webhook-tls", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return secret
+	}
+
+	// verify checks that the serving certificate of the secret is trusted by
+	// the CA bundle for the service, and returns it
+	verify := func(caBundle []byte) *x509.Certificate {
+		secret := getSecret()
+		cert, err := parseKeyPair(keyPairPEM{cert: secret.Data[v1.TLSCertKey], key: secret.Data[v1.TLSPrivateKeyKey]})
+		Expect(err).NotTo(HaveOccurred())
+		roots := x509.NewCertPool()
+		Expect(roots.AppendCertsFromPEM(caBundle)).To(BeTrue())
+		_, err = cert.Leaf.Verify(x509.VerifyOptions{
+			DNSName:     "webhook.kube-system.svc",
+			Roots:       roots,
+			CurrentTime: current,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return cert.Leaf
+	}
+
+	validatingCABundle := func(name string) []byte {
+		configuration, err := fakeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return configuration.Webhooks[0].ClientConfig.CABundle
+	}
+
+	secretWrites := func() int {
+		writes := 0
+		for _, action := range fakeClient.Actions() {
+			if action.GetResource().Resource == "secrets" && (action.GetVerb() == "create" || action.GetVerb() == "update") {
+				writes++
+			}
+		}
+		return writes
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		config = &CertRotationConfig{
+			SecretNamespace:  "kube-system",
+			SecretName:       "webhook-tls",
+			ServiceNamespace: "kube-system",
+			ServiceName:      "webhook",
+			CAValidity:       DefaultCAValidity,
+			CertValidity:     DefaultCertValidity,
+			RotateBefore:     DefaultCertRotateBefore,
+		}
+		fakeClient = fake.NewSimpleClientset(
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "validate"},
+				Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "validate.k8s.io", ClientConfig: webhookClientConfig("webhook")}},
+			},
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "isolate"},
+				Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "isolate.k8s.io", ClientConfig: webhookClientConfig("webhook")}},
+			},
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "other"},
+				Webhooks:   []admissionregistrationv1.ValidatingWebhook{{Name: "other.k8s.io", ClientConfig: webhookClientConfig("other")}},
+			},
+			&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "mutate"},
+				Webhooks:   []admissionregistrationv1.MutatingWebhook{{Name: "mutate.k8s.io", ClientConfig: webhookClientConfig("webhook")}},
+			},
+		)
+		oldClient = clientset
+		clientset = fakeClient
+		current = time.Now()
+		now = func() time.Time { return current }
+	})
+
+	AfterEach(func() {
+		clientset = oldClient
+		now = time.Now
+	})
+
+	It("should require certificates valid longer than the rotation delay", func() {
+		Expect(config.Validate()).To(Succeed())
+		config.CertValidity = config.RotateBefore
+		Expect(config.Validate()).To(MatchError(ContainSubstring("serving certificate validity")))
+		config.CertValidity = DefaultCertValidity
+		config.CAValidity = config.RotateBefore / 2
+		Expect(config.Validate()).To(MatchError(ContainSubstring("CA validity")))
+	})
+
+	It("should generate the certificates and patch the webhook configurations", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+
+		secret := getSecret()
+		Expect(secret.Type).To(Equal(v1.SecretTypeTLS))
+		caBundle := secret.Data[CACertKey]
+		cert := verify(caBundle)
+		Expect(cert.DNSNames).To(ConsistOf("webhook", "webhook.kube-system", "webhook.kube-system.svc", "webhook.kube-system.svc.cluster.local"))
+
+		Expect(validatingCABundle("validate")).To(Equal(caBundle))
+		Expect(validatingCABundle("isolate")).To(Equal(caBundle))
+		Expect(validatingCABundle("other")).To(Equal([]byte("stale")))
+		mutating, err := fakeClient.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, "mutate", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mutating.Webhooks[0].ClientConfig.CABundle).To(Equal(caBundle))
+	})
+
+	It("should keep valid certificates", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		secret := getSecret()
+		fakeClient.ClearActions()
+
+		current = current.Add(300 * 24 * time.Hour)
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		Expect(getSecret().Data).To(Equal(secret.Data))
+		for _, action := range fakeClient.Actions() {
+			Expect(action.GetVerb()).NotTo(Equal("update"), "unexpected %v", action)
+		}
+	})
+
+	It("should rotate the serving certificate before it expires", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		secret := getSecret()
+		first := verify(secret.Data[CACertKey])
+
+		current = current.Add(DefaultCertValidity - DefaultCertRotateBefore + time.Hour)
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		Expect(getSecret().Data[CACertKey]).To(Equal(secret.Data[CACertKey]))
+		second := verify(secret.Data[CACertKey])
+		Expect(second.SerialNumber).NotTo(Equal(first.SerialNumber))
+		Expect(second.NotAfter).To(BeTemporally(">", first.NotAfter))
+	})
+
+	It("should rotate the CA and trust both CAs until the previous one expires", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		firstBundle := getSecret().Data[CACertKey]
+
+		current = current.Add(DefaultCAValidity - DefaultCertRotateBefore + time.Hour)
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		secondBundle := getSecret().Data[CACertKey]
+		Expect(parseCertificates(secondBundle)).To(HaveLen(2))
+		Expect(parseCertificates(secondBundle)[1].Raw).To(Equal(parseCertificates(firstBundle)[0].Raw))
+		Expect(validatingCABundle("validate")).To(Equal(secondBundle))
+		// the serving certificate is signed by the new CA
+		verify(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: parseCertificates(secondBundle)[0].Raw}))
+
+		current = current.Add(DefaultCertRotateBefore)
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		Expect(parseCertificates(getSecret().Data[CACertKey])).To(HaveLen(1))
+	})
+
+	It("should only patch the webhook configurations once the secret is created", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		var verbs []string
+		for _, action := range fakeClient.Actions() {
+			if action.GetVerb() == "create" || action.GetVerb() == "update" {
+				verbs = append(verbs, action.GetVerb()+" "+action.GetResource().Resource)
+			}
+		}
+		Expect(verbs[0]).To(Equal("create secrets"))
+		Expect(verbs[1:]).To(HaveEach(HavePrefix("update")))
+	})
+
+	It("should patch the webhook configurations before storing a new CA", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		stored := getSecret().Data
+		fakeClient.ClearActions()
+		fakeClient.PrependReactor("update", "validatingwebhookconfigurations", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, fmt.Errorf("connection refused")
+		})
+
+		current = current.Add(DefaultCAValidity - DefaultCertRotateBefore + time.Hour)
+		Expect(EnsureCertificates(ctx, config)).To(MatchError(ContainSubstring("connection refused")))
+		Expect(secretWrites()).To(Equal(0))
+		Expect(getSecret().Data).To(Equal(stored))
+	})
+
+	It("should use the certificates of the replica that created the secret first", func() {
+		// the certificates of another replica
+		other := fake.NewSimpleClientset()
+		clientset = other
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		otherSecret, err := other.CoreV1().Secrets("kube-system").Get(ctx, "webhook-tls", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		clientset = fakeClient
+
+		fakeClient.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			Expect(fakeClient.Tracker().Add(otherSecret)).To(Succeed())
+			return true, nil, apierrors.NewAlreadyExists(v1.Resource("secrets"), "webhook-tls")
+		})
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		Expect(getSecret().Data).To(Equal(otherSecret.Data))
+		Expect(validatingCABundle("validate")).To(Equal(otherSecret.Data[CACertKey]))
+		verify(validatingCABundle("isolate"))
+	})
+
+	It("should restore the stored CA bundle when the secret was updated by another replica", func() {
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		stored := getSecret().Data
+		fakeClient.PrependReactor("update", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewConflict(v1.Resource("secrets"), "webhook-tls", fmt.Errorf("the object has been modified"))
+		})
+
+		current = current.Add(DefaultCAValidity - DefaultCertRotateBefore + time.Hour)
+		err := EnsureCertificates(ctx, config)
+		Expect(apierrors.IsConflict(err)).To(BeTrue())
+		Expect(validatingCABundle("validate")).To(Equal(stored[CACertKey]))
+		Expect(validatingCABundle("isolate")).To(Equal(stored[CACertKey]))
+	})
+
+	It("should replace an invalid secret", func() {
+		_, err := fakeClient.CoreV1().Secrets("kube-system").Create(ctx, &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-tls", Namespace: "kube-system"},
+			Data:       map[string][]byte{v1.TLSCertKey: []byte("invalid")},
+		}, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(EnsureCertificates(ctx, config)).To(Succeed())
+		verify(getSecret().Data[CACertKey])
+	})
+})
@@ -0,0 +1,201 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"errors"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/cache"
+)
+
+// CheckFailure is a failure of an admission check, a check may fail several
+// times for an object
+type CheckFailure struct {
+	// Check is the name of the failed check
+	Check string
+	// Rule is the name of the failed CEL rule, if Check is CheckRules
+	Rule string
+	// Cause is the failure with the path of the offending field, if known
+	Cause *field.Error
+}
+
+// Message describes the failure, prefixed with the field path if known
+func (f CheckFailure) Message() string {
+	if f.Cause.Field == "" {
+		return f.Cause.Detail
+	}
+	return f.Cause.Error()
+}
+
+// toFieldErrors flattens the error returned by a check into field errors,
+// errors without a field are attributed to path
+func toFieldErrors(err error, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var errs []error
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		errs = agg.Errors()
+	} else {
+		errs = []error{err}
+	}
+	for _, err := range errs {
+		var fieldErr *field.Error
+		if errors.As(err, &fieldErr) {
+			allErrs = append(allErrs, fieldErr)
+		} else if path != nil {
+			allErrs = append(allErrs, field.InternalError(path, err))
+		} else {
+			allErrs = append(allErrs, &field.Error{Type: field.ErrorTypeInternal, Detail: err.Error()})
+		}
+	}
+	return allErrs
+}
+
+// newCheckFailures returns a failure for each error of the check
+func newCheckFailures(check string, err error) []CheckFailure {
+	var failures []CheckFailure
+	var errs []error
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		errs = agg.Errors()
+	} else {
+		errs = []error{err}
+	}
+	for _, err := range errs {
+		var rErr *ruleError
+		if errors.As(err, &rErr) {
+			failures = append(failures, CheckFailure{
+				Check: check,
+				Rule:  rErr.rule,
+				Cause: &field.Error{Type: field.ErrorTypeForbidden, Detail: rErr.Error()},
+			})
+			continue
+		}
+		for _, fieldErr := range toFieldErrors(err, nil) {
+			failures = append(failures, CheckFailure{Check: check, Cause: fieldErr})
+		}
+	}
+	return failures
+}
+
+// netAttachDefChecks runs every net-attach-def check, it returns the failures in
+// the order of the checks and the warnings of the rules and the deprecation
+// catalog. The quota only applies to the creation of net-attach-defs.
+func netAttachDefChecks(netAttachDef netv1.NetworkAttachmentDefinition, namespace string, create bool) ([]CheckFailure, []string) {
+	var failures []CheckFailure
+
+	if _, err := validateNetworkAttachmentDefinition(netAttachDef); err != nil {
+		failures = append(failures, newCheckFailures(CheckConfig, err)...)
+	}
+	if _, err := validateNetworkAttachmentPolicies(netAttachDef, namespace); err != nil {
+		failures = append(failures, newCheckFailures(CheckPolicy, err)...)
+	}
+	if _, err := validateTuningRestrictions(netAttachDef, namespace); err != nil {
+		failures = append(failures, newCheckFailures(CheckTuning, err)...)
+	}
+	if create {
+		if _, err := validateNetAttachDefQuota(namespace); err != nil {
+			failures = append(failures, newCheckFailures(CheckQuota, err)...)
+		}
+	}
+	warnings, err := validateNetAttachDefRules(netAttachDef, namespace)
+	if err != nil {
+		failures = append(failures, newCheckFailures(CheckRules, err)...)
+	}
+	warnings = append(warnings, netAttachDefDeprecations(netAttachDef, namespace)...)
+	return failures, warnings
+}
+
+// podChecks runs every pod check, it returns the failures in the order of the
+// checks and the warnings of the rules and the deprecation catalog
+func podChecks(pod v1.Pod, namespace string) ([]CheckFailure, []string) {
+	var failures []CheckFailure
+
+	if _, err := validatePodIsolation(pod, namespace); err != nil {
+		failures = append(failures, newCheckFailures(CheckIsolation, err)...)
+	}
+	if _, err := validatePodNetworkQuota(pod, namespace); err != nil {
+		failures = append(failures, newCheckFailures(CheckQuota, err)...)
+	}
+	if _, err := validateMaxAttachments(pod, namespace); err != nil {
+		failures = append(failures, newCheckFailures(CheckMaxAttachments, err)...)
+	}
+	warnings, err := validatePodRules(pod, namespace)
+	if err != nil {
+		failures = append(failures, newCheckFailures(CheckRules, err)...)
+	}
+	warnings = append(warnings, podDeprecations(pod, namespace)...)
+	return failures, warnings
+}
+
+// enforceFailures applies the enforcement modes of the namespace to the failures,
+// it returns the failures that are enforced
+func enforceFailures(namespace string, failures []CheckFailure, warnings *[]string) []CheckFailure {
+	var enforced []CheckFailure
+	for _, failure := range failures {
+		if enforce(failure, namespace, warnings) {
+			enforced = append(enforced, failure)
+		}
+	}
+	return enforced
+}
+
+// CheckNetworkAttachmentDefinition runs the checks of /validate on an existing
+// net-attach-def, regardless of the enforcement modes
+func CheckNetworkAttachmentDefinition(netAttachDef netv1.NetworkAttachmentDefinition) []CheckFailure {
+	failures, _ := netAttachDefChecks(netAttachDef, netAttachDef.GetNamespace(), false)
+	return failures
+}
+
+// CheckPod runs the checks of /isolate on an existing pod, regardless of the
+// enforcement modes
+func CheckPod(pod v1.Pod) []CheckFailure {
+	failures, _ := podChecks(pod, pod.GetNamespace())
+	return failures
+}
+
+// SetCaches replaces the namespace, net-attach-def and policy caches with the
+// given objects, for checking objects without informers. Policies are disabled
+// when policies is nil.
+func SetCaches(namespaces []*v1.Namespace, netAttachDefs []*netv1.NetworkAttachmentDefinition, policies []*NetworkAttachmentPolicy) error {
+	nsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range namespaces {
+		if err := nsIndexer.Add(ns); err != nil {
+			return err
+		}
+	}
+	nadIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, netAttachDef := range netAttachDefs {
+		if err := nadIndexer.Add(netAttachDef); err != nil {
+			return err
+		}
+	}
+	var polIndexer cache.Indexer
+	if policies != nil {
+		polIndexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		for _, policy := range policies {
+			if err := polIndexer.Add(policy); err != nil {
+				return err
+			}
+		}
+	}
+
+	namespaceIndexer = nsIndexer
+	netAttachDefIndexer = nadIndexer
+	policyIndexer = polIndexer
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// ClientAuth verifies the client certificates presented to the webhook, so that
+// only the API server can submit AdmissionReviews. The client CA bundle is
+// reloaded when its file changes.
+type ClientAuth struct {
+	caFile string
+	// allowedSubjects are the common names of the client certificates allowed,
+	// any client certificate signed by the CA is allowed if it is empty
+	allowedSubjects []string
+
+	poolMutex sync.RWMutex
+	caBundle  []byte
+	pool      *x509.CertPool
+}
+
+// reload loads the client CA bundle, the current one is kept if the file does
+// not hold any certificate
+func (auth *ClientAuth) reload() error {
+	caBundle, err := os.ReadFile(auth.caFile)
+	if err != nil {
+		return fmt.Errorf("failed to reload client CA bundle: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return fmt.Errorf("failed to reload client CA bundle: no certificate found in %s", auth.caFile)
+	}
+
+	auth.poolMutex.Lock()
+	defer auth.poolMutex.Unlock()
+	if bytes.Equal(auth.caBundle, caBundle) {
+		return nil
+	}
+	if auth.pool != nil {
+		glog.Infof("client CA bundle reloaded from %s", auth.caFile)
+	}
+	auth.caBundle = caBundle
+	auth.pool = pool
+	return nil
+}
+
+// verify checks that the client certificate is signed by the client CA and
+// that its subject is allowed
+func (auth *ClientAuth) verify(state tls.ConnectionState) error {
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("no client certificate")
+	}
+	leaf := state.PeerCertificates[0]
+
+	auth.poolMutex.RLock()
+	pool := auth.pool
+	auth.poolMutex.RUnlock()
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         pool,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		glog.Warningf("rejecting client certificate %q: %v", leaf.Subject, err)
+		return err
+	}
+
+	if len(auth.allowedSubjects) == 0 {
+		return nil
+	}
+	for _, subject := range auth.allowedSubjects {
+		if leaf.Subject.CommonName == subject {
+			return nil
+		}
+	}
+	glog.Warningf("rejecting client certificate %q: subject not allowed", leaf.Subject)
+	return fmt.Errorf("client certificate subject %q is not allowed", leaf.Subject)
+}
+
+// ApplyTo makes the TLS configuration require and verify client certificates.
+// They are verified in VerifyConnection rather than with ClientCAs, so that
+// the reloaded CA bundle also applies to resumed sessions.
+func (auth *ClientAuth) ApplyTo(config *tls.Config) {
+	config.ClientAuth = tls.RequireAnyClientCert
+	config.VerifyConnection = auth.verify
+}
+
+// NewClientAuth loads the client CA bundle and reloads it whenever the file
+// changes, until stopCh is closed
+func NewClientAuth(caFile string, allowedSubjects []string, stopCh <-chan struct{}) (*ClientAuth, error) {
+	auth := &ClientAuth{
+		caFile:          caFile,
+		allowedSubjects: allowedSubjects,
+	}
+	if err := auth.reload(); err != nil {
+		return nil, err
+	}
+	if err := watchFiles("client CA bundle", []string{caFile}, auth.reload, stopCh); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// newTestCA returns a PEM encoded CA and the key pair to sign with it
+func newTestCA(commonName string) (keyPairPEM, *tls.Certificate) {
+	caPEM, err := generateCertificate(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil)
+	Expect(err).NotTo(HaveOccurred())
+	ca, err := parseKeyPair(caPEM)
+	Expect(err).NotTo(HaveOccurred())
+	return caPEM, ca
+}
+
+// newTestClientCert returns a client certificate signed by the CA
+func newTestClientCert(ca *tls.Certificate, commonName string) tls.Certificate {
+	certPEM, err := generateCertificate(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName, Organization: []string{"system:masters"}},
+		NotBefore:   time.Now().Add(-time.Hour),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := tls.X509KeyPair(certPEM.cert, certPEM.key)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+var _ = Describe("Client certificate authentication", func() {
+	var (
+		dir    string
+		caFile string
+		stopCh chan struct{}
+		ca     *tls.Certificate
+		server *httptest.Server
+	)
+
+	// get sends a request with the client certificates
+	get := func(certs ...tls.Certificate) error {
+		client := server.Client()
+		transport := client.Transport.(*http.Transport)
+		transport.TLSClientConfig.Certificates = certs
+		transport.DisableKeepAlives = true
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	start := func(allowedSubjects []string) {
+		auth, err := NewClientAuth(caFile, allowedSubjects, stopCh)
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		server.TLS = &tls.Config{}
+		auth.ApplyTo(server.TLS)
+		server.StartTLS()
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "client-ca")
+		Expect(err).NotTo(HaveOccurred())
+		caFile = filepath.Join(dir, "ca.crt")
+		var caPEM keyPairPEM
+		caPEM, ca = newTestCA("client-ca")
+		Expect(os.WriteFile(caFile, caPEM.cert, 0600)).To(Succeed())
+		stopCh = make(chan struct{})
+		certReloadSettleDelay = 10 * time.Millisecond
+		server = nil
+	})
+
+	AfterEach(func() {
+		if server != nil {
+			server.Close()
+		}
+		close(stopCh)
+		os.RemoveAll(dir)
+		certReloadSettleDelay = 100 * time.Millisecond
+	})
+
+	It("should fail without a client CA bundle", func() {
+		Expect(os.WriteFile(caFile, []byte("invalid"), 0600)).To(Succeed())
+		_, err := NewClientAuth(caFile, nil, stopCh)
+		Expect(err).To(MatchError(ContainSubstring("no certificate found")))
+	})
+
+	It("should require a client certificate signed by the client CA", func() {
+		start(nil)
+		Expect(get(newTestClientCert(ca, "kube-apiserver"))).To(Succeed())
+		Expect(get()).NotTo(Succeed())
+		_, otherCA := newTestCA("other-ca")
+		Expect(get(newTestClientCert(otherCA, "kube-apiserver"))).NotTo(Succeed())
+	})
+
+	It("should only allow the listed subjects", func() {
+		start([]string{"kube-apiserver", "apiserver-webhook-client"})
+		Expect(get(newTestClientCert(ca, "apiserver-webhook-client"))).To(Succeed())
+		Expect(get(newTestClientCert(ca, "someone"))).NotTo(Succeed())
+	})
+
+	It("should reload the client CA bundle", func() {
+		start(nil)
+		newCAPEM, newCA := newTestCA("new-client-ca")
+		tmp := caFile + ".tmp"
+		Expect(os.WriteFile(tmp, newCAPEM.cert, 0600)).To(Succeed())
+		Expect(os.Rename(tmp, caFile)).To(Succeed())
+
+		Eventually(func() error { return get(newTestClientCert(newCA, "kube-apiserver")) }).Should(Succeed())
+		Expect(get(newTestClientCert(ca, "kube-apiserver"))).NotTo(Succeed())
+	})
+})
@@ -0,0 +1,243 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DeprecationKindCNIVersion deprecates a cniVersion of net-attach-def configs
+	DeprecationKindCNIVersion = "cniVersion"
+	// DeprecationKindPlugin deprecates a CNI plugin type
+	DeprecationKindPlugin = "plugin"
+	// DeprecationKindAnnotationFormat deprecates a format of the pod network annotations
+	DeprecationKindAnnotationFormat = "annotationFormat"
+
+	// AnnotationFormatCommaDelimited is the comma-delimited list of network names,
+	// e.g. "net1,other/net2@eth2", as opposed to the JSON format
+	AnnotationFormatCommaDelimited = "comma-delimited"
+
+	sunsetDateLayout = "2006-01-02"
+)
+
+// Deprecation is an entry of the deprecation catalog. Requests using the
+// deprecated feature are allowed with a warning.
+type Deprecation struct {
+	// Name identifies the feature in metrics, defaults to <kind>/<value>
+	Name string `json:"name,omitempty"`
+	Kind string `json:"kind"`
+	// Value is the cniVersion, the plugin type or the annotation format
+	Value string `json:"value"`
+	// Sunset is the date support ends, in YYYY-MM-DD format
+	Sunset string `json:"sunset,omitempty"`
+	// Message is appended to the warning, e.g. to name a replacement
+	Message string `json:"message,omitempty"`
+}
+
+// DeprecationFile is the format of the deprecation catalog file
+type DeprecationFile struct {
+	Deprecations []Deprecation `json:"deprecations"`
+}
+
+type deprecationCatalog struct {
+	cniVersions       map[string]Deprecation
+	plugins           map[string]Deprecation
+	annotationFormats map[string]Deprecation
+	sunsets           map[string]time.Time
+}
+
+var (
+	deprecationsMutex sync.RWMutex
+	deprecations      = &deprecationCatalog{}
+
+	// now returns the current time, replaced in tests
+	now = time.Now
+)
+
+// feature returns the name of the deprecated feature
+func (d Deprecation) feature() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return d.Kind + "/" + d.Value
+}
+
+// newDeprecationCatalog validates the deprecations and indexes them by kind
+func newDeprecationCatalog(list []Deprecation) (*deprecationCatalog, error) {
+	catalog := &deprecationCatalog{
+		cniVersions:       map[string]Deprecation{},
+		plugins:           map[string]Deprecation{},
+		annotationFormats: map[string]Deprecation{},
+		sunsets:           map[string]time.Time{},
+	}
+	features := map[string]bool{}
+	for _, d := range list {
+		if d.Value == "" {
+			return nil, fmt.Errorf("deprecation of kind %q has no value", d.Kind)
+		}
+		var index map[string]Deprecation
+		switch d.Kind {
+		case DeprecationKindCNIVersion:
+			index = catalog.cniVersions
+		case DeprecationKindPlugin:
+			index = catalog.plugins
+		case DeprecationKindAnnotationFormat:
+			if d.Value != AnnotationFormatCommaDelimited {
+				return nil, fmt.Errorf("deprecation %q: invalid annotation format %q, must be %s", d.feature(), d.Value, AnnotationFormatCommaDelimited)
+			}
+			index = catalog.annotationFormats
+		default:
+			return nil, fmt.Errorf("deprecation %q: invalid kind %q, must be one of %s, %s, %s", d.feature(), d.Kind,
+				DeprecationKindCNIVersion, DeprecationKindPlugin, DeprecationKindAnnotationFormat)
+		}
+		if features[d.feature()] || index[d.Value].Value != "" {
+			return nil, fmt.Errorf("deprecation %q is defined more than once", d.feature())
+		}
+		features[d.feature()] = true
+		if d.Sunset != "" {
+			sunset, err := time.Parse(sunsetDateLayout, d.Sunset)
+			if err != nil {
+				return nil, fmt.Errorf("deprecation %q: invalid sunset date %q, must be in YYYY-MM-DD format", d.feature(), d.Sunset)
+			}
+			catalog.sunsets[d.feature()] = sunset
+		}
+		index[d.Value] = d
+	}
+	return catalog, nil
+}
+
+// setDeprecations replaces the current catalog if all deprecations are valid
+func setDeprecations(list []Deprecation) error {
+	catalog, err := newDeprecationCatalog(list)
+	if err != nil {
+		return err
+	}
+	deprecationsMutex.Lock()
+	defer deprecationsMutex.Unlock()
+	deprecations = catalog
+	return nil
+}
+
+func currentDeprecations() *deprecationCatalog {
+	deprecationsMutex.RLock()
+	defer deprecationsMutex.RUnlock()
+	return deprecations
+}
+
+// LoadDeprecations reads the deprecation catalog file, the current catalog is kept on error
+func LoadDeprecations(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file DeprecationFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return fmt.Errorf("error parsing deprecations file %s: %v", path, err)
+	}
+	if err := setDeprecations(file.Deprecations); err != nil {
+		return fmt.Errorf("error loading deprecations file %s: %v", path, err)
+	}
+	glog.Infof("loaded %d deprecations from %s", len(file.Deprecations), path)
+	configFileLoaded("deprecations")
+	return nil
+}
+
+// WatchDeprecations reloads the deprecation catalog file whenever it changes
+func WatchDeprecations(path string, stopCh <-chan struct{}) error {
+	return watchFile(path, "deprecations", LoadDeprecations, stopCh)
+}
+
+// warning describes the use of the deprecated feature, subject is what the
+// request uses, and counts the use in the namespace
+func (c *deprecationCatalog) warning(d Deprecation, subject, namespace string) string {
+	localmetrics.IncDeprecatedFeatureUses(d.feature(), namespace)
+
+	warning := subject + " is deprecated"
+	if sunset, ok := c.sunsets[d.feature()]; ok {
+		if now().Before(sunset) {
+			warning += fmt.Sprintf(", support ends on %s", d.Sunset)
+		} else {
+			warning += fmt.Sprintf(", support ended on %s", d.Sunset)
+		}
+	}
+	if d.Message != "" {
+		warning += ": " + d.Message
+	}
+	return warning
+}
+
+// netAttachDefDeprecations returns a warning for each deprecated cniVersion and
+// plugin type the net-attach-def uses. Invalid configs are left to the config check.
+func netAttachDefDeprecations(netAttachDef netv1.NetworkAttachmentDefinition, namespace string) []string {
+	catalog := currentDeprecations()
+	if len(catalog.cniVersions) == 0 && len(catalog.plugins) == 0 {
+		return nil
+	}
+
+	var warnings []string
+	var config struct {
+		CNIVersion string `json:"cniVersion"`
+	}
+	if err := json.Unmarshal([]byte(netAttachDef.Spec.Config), &config); err != nil {
+		return nil
+	}
+	if d, ok := catalog.cniVersions[config.CNIVersion]; ok {
+		warnings = append(warnings, catalog.warning(d, fmt.Sprintf("%s: cniVersion %q", configPath.Child("cniVersion"), config.CNIVersion), namespace))
+	}
+
+	plugins, err := getCNIPlugins([]byte(netAttachDef.Spec.Config))
+	if err != nil {
+		return warnings
+	}
+	for _, plugin := range plugins {
+		pluginType, _ := plugin.conf["type"].(string)
+		if d, ok := catalog.plugins[pluginType]; ok {
+			warnings = append(warnings, catalog.warning(d, fmt.Sprintf("%s: CNI plugin type %q", plugin.path.Child("type"), pluginType), namespace))
+		}
+	}
+	return warnings
+}
+
+// podDeprecations returns a warning for each pod network annotation in a
+// deprecated format
+func podDeprecations(pod v1.Pod, namespace string) []string {
+	catalog := currentDeprecations()
+	d, ok := catalog.annotationFormats[AnnotationFormatCommaDelimited]
+	if !ok {
+		return nil
+	}
+
+	var warnings []string
+	for _, key := range []string{networksAnnotationKey, defaultNetworkAnnotationKey} {
+		value := strings.TrimSpace(pod.GetAnnotations()[key])
+		if value == "" || strings.IndexAny(value, "[{\"") >= 0 {
+			continue
+		}
+		warnings = append(warnings, catalog.warning(d, fmt.Sprintf("%s: comma-delimited format", annotationsPath.Key(key)), namespace))
+	}
+	return warnings
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	dto "github.com/prometheus/client_model/go"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// deprecatedFeatureUses returns the value of the deprecation counter
+func deprecatedFeatureUses(feature, namespace string) float64 {
+	metric := &dto.Metric{}
+	Expect(localmetrics.DeprecatedFeatureUses.WithLabelValues(feature, namespace).Write(metric)).To(Succeed())
+	return metric.GetCounter().GetValue()
+}
+
+var _ = Describe("Deprecations", func() {
+	BeforeEach(func() {
+		now = func() time.Time { return time.Date(2027, 3, 1, 12, 0, 0, 0, time.UTC) }
+		Expect(setDeprecations([]Deprecation{
+			{Kind: DeprecationKindCNIVersion, Value: "0.1.0", Sunset: "2027-01-01"},
+			{Kind: DeprecationKindCNIVersion, Value: "0.2.0", Sunset: "2027-06-30", Message: "use 0.4.0 or later"},
+			{Name: "flannel-plugin", Kind: DeprecationKindPlugin, Value: "flannel"},
+			{Kind: DeprecationKindAnnotationFormat, Value: AnnotationFormatCommaDelimited, Message: "use the JSON format"},
+		})).To(Succeed())
+	})
+
+	AfterEach(func() {
+		now = time.Now
+		Expect(setDeprecations(nil)).To(Succeed())
+		localmetrics.DeprecatedFeatureUses.Reset()
+	})
+
+	DescribeTable("loading an invalid catalog",
+		func(deprecation Deprecation, message string) {
+			Expect(setDeprecations([]Deprecation{deprecation})).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without a value", Deprecation{Kind: DeprecationKindPlugin}, "has no value"),
+		Entry("with an invalid kind", Deprecation{Kind: "sysctl", Value: "x"}, `invalid kind "sysctl"`),
+		Entry("with an invalid annotation format", Deprecation{Kind: DeprecationKindAnnotationFormat, Value: "yaml"}, `invalid annotation format "yaml"`),
+		Entry("with an invalid sunset date", Deprecation{Kind: DeprecationKindPlugin, Value: "x", Sunset: "next year"}, "invalid sunset date"),
+	)
+
+	It("should reject duplicate deprecations", func() {
+		d := Deprecation{Kind: DeprecationKindPlugin, Value: "flannel"}
+		Expect(setDeprecations([]Deprecation{d, d})).To(MatchError(ContainSubstring("more than once")))
+	})
+
+	DescribeTable("net-attach-def warnings",
+		func(config string, warnings ...string) {
+			netAttachDef := netv1.NetworkAttachmentDefinition{Spec: netv1.NetworkAttachmentDefinitionSpec{Config: config}}
+			Expect(netAttachDefDeprecations(netAttachDef, "tenant")).To(ConsistOf(warnings))
+		},
+		Entry("a supported config", `{"cniVersion": "0.4.0", "type": "bridge"}`),
+		Entry("an invalid config", `{"cniVersion": "0.1.0"`),
+		Entry("a cniVersion past its sunset", `{"cniVersion": "0.1.0", "type": "bridge"}`,
+			`spec.config.cniVersion: cniVersion "0.1.0" is deprecated, support ended on 2027-01-01`),
+		Entry("a cniVersion before its sunset", `{"cniVersion": "0.2.0", "type": "bridge"}`,
+			`spec.config.cniVersion: cniVersion "0.2.0" is deprecated, support ends on 2027-06-30: use 0.4.0 or later`),
+		Entry("a deprecated plugin", `{"cniVersion": "0.4.0", "plugins": [{"type": "bridge"}, {"type": "flannel"}]}`,
+			`spec.config.plugins[1].type: CNI plugin type "flannel" is deprecated`),
+	)
+
+	DescribeTable("pod warnings",
+		func(annotations map[string]string, warnings ...string) {
+			Expect(podDeprecations(newPod(annotations), "tenant")).To(ConsistOf(warnings))
+		},
+		Entry("no annotations", nil),
+		Entry("the JSON format", map[string]string{networksAnnotationKey: `[{"name": "net1"}]`}),
+		Entry("the comma-delimited format", map[string]string{networksAnnotationKey: "net1,net2"},
+			"metadata.annotations[k8s.v1.cni.cncf.io/networks]: comma-delimited format is deprecated: use the JSON format"),
+		Entry("a comma-delimited default network", map[string]string{defaultNetworkAnnotationKey: "net1"},
+			"metadata.annotations[v1.multus-cni.io/default-network]: comma-delimited format is deprecated: use the JSON format"),
+	)
+
+	It("should count the uses of each feature by namespace", func() {
+		netAttachDef := netv1.NetworkAttachmentDefinition{Spec: netv1.NetworkAttachmentDefinitionSpec{Config: `{"cniVersion": "0.2.0", "type": "flannel"}`}}
+		netAttachDefDeprecations(netAttachDef, "tenant")
+		netAttachDefDeprecations(netAttachDef, "tenant")
+		netAttachDefDeprecations(netAttachDef, "infra")
+
+		Expect(deprecatedFeatureUses("cniVersion/0.2.0", "tenant")).To(Equal(2.0))
+		Expect(deprecatedFeatureUses("flannel-plugin", "infra")).To(Equal(1.0))
+	})
+
+	It("should allow the request with the warnings", func() {
+		netAttachDef := netv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: "old-net"},
+			Spec:       netv1.NetworkAttachmentDefinitionSpec{Config: `{"cniVersion": "0.2.0", "type": "flannel"}`},
+		}
+		resp := postAdmissionReview(ValidateHandler, "tenant", admissionv1.Create, netAttachDef)
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Warnings).To(ConsistOf(
+			ContainSubstring(`cniVersion "0.2.0" is deprecated`),
+			ContainSubstring(`CNI plugin type "flannel" is deprecated`),
+		))
+
+		resp = postAdmissionReview(IsolateHandler, "tenant", admissionv1.Create, newPod(map[string]string{networksAnnotationKey: "net1"}))
+		Expect(resp.Allowed).To(BeTrue())
+		Expect(resp.Warnings).To(ConsistOf(ContainSubstring("comma-delimited format is deprecated")))
+	})
+
+	It("should load the catalog from a file", func() {
+		dir, err := os.MkdirTemp("", "deprecations")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "deprecations.yaml")
+		Expect(os.WriteFile(path, []byte(`deprecations:
+- kind: plugin
+  value: macvlan
+  sunset: "2028-01-01"
+`), 0644)).To(Succeed())
+
+		Expect(LoadDeprecations(path)).To(Succeed())
+		Expect(currentDeprecations().plugins).To(HaveKey("macvlan"))
+		Expect(currentDeprecations().cniVersions).To(BeEmpty())
+
+		Expect(os.WriteFile(path, []byte("deprecations:\n- kind: plugin\n  valeu: ipvlan\n"), 0644)).To(Succeed())
+		Expect(LoadDeprecations(path)).NotTo(Succeed())
+		Expect(currentDeprecations().plugins).To(HaveKey("macvlan"))
+	})
+})
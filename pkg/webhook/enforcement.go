@@ -0,0 +1,162 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+)
+
+// enforcementModeAnnotationKey is the namespace annotation overriding the enforcement modes
+const enforcementModeAnnotationKey = "k8s.v1.cni.cncf.io/enforcement-mode"
+
+// EnforcementMode defines what happens when an admission check fails
+type EnforcementMode string
+
+const (
+	// EnforcementModeEnforce denies the request
+	EnforcementModeEnforce EnforcementMode = "enforce"
+	// EnforcementModeWarn allows the request and returns the failure as a warning
+	EnforcementModeWarn EnforcementMode = "warn"
+	// EnforcementModeAudit allows the request and only logs the failure
+	EnforcementModeAudit EnforcementMode = "audit"
+)
+
+// names of the admission checks whose enforcement mode can be set
+const (
+	CheckIsolation      = "isolation"
+	CheckConfig         = "config"
+	CheckPolicy         = "policy"
+	CheckTuning         = "tuning"
+	CheckQuota          = "quota"
+	CheckMaxAttachments = "max-attachments"
+	CheckRules          = "rules"
+)
+
+// Checks lists the names of the admission checks
+var Checks = []string{CheckIsolation, CheckConfig, CheckPolicy, CheckTuning, CheckQuota, CheckMaxAttachments, CheckRules}
+
+// EnforcementModes holds a default mode and the modes of individual checks
+type EnforcementModes struct {
+	// Default applies to the checks without a mode of their own, if set
+	Default EnforcementMode
+	// Checks maps check names to their mode
+	Checks map[string]EnforcementMode
+}
+
+var enforcementModes = EnforcementModes{Default: EnforcementModeEnforce}
+
+// SetEnforcementModes configures the global enforcement modes, namespaces may
+// override them with an annotation
+func SetEnforcementModes(modes EnforcementModes) {
+	if modes.Default == "" {
+		modes.Default = EnforcementModeEnforce
+	}
+	enforcementModes = modes
+}
+
+func parseEnforcementMode(value string) (EnforcementMode, error) {
+	switch mode := EnforcementMode(value); mode {
+	case EnforcementModeEnforce, EnforcementModeWarn, EnforcementModeAudit:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid enforcement mode %q, must be one of enforce, warn, audit", value)
+}
+
+// ParseEnforcementModes parses a comma separated list of a default mode and
+// check=mode pairs, e.g. "warn,quota=enforce"
+func ParseEnforcementModes(value string) (EnforcementModes, error) {
+	modes := EnforcementModes{Checks: map[string]EnforcementMode{}}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		check, modeName := "", entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			check, modeName = entry[:i], entry[i+1:]
+		}
+		mode, err := parseEnforcementMode(modeName)
+		if err != nil {
+			return EnforcementModes{}, err
+		}
+		if check == "" {
+			modes.Default = mode
+			continue
+		}
+		if !containsString(Checks, check) {
+			return EnforcementModes{}, fmt.Errorf("unknown check %q, must be one of %s", check, strings.Join(Checks, ", "))
+		}
+		modes.Checks[check] = mode
+	}
+	return modes, nil
+}
+
+// namespaceEnforcementModes returns the modes set by the namespace annotation
+func namespaceEnforcementModes(namespace string) EnforcementModes {
+	ns := getNamespace(namespace)
+	if ns == nil {
+		return EnforcementModes{}
+	}
+	value, ok := ns.GetAnnotations()[enforcementModeAnnotationKey]
+	if !ok {
+		return EnforcementModes{}
+	}
+	modes, err := ParseEnforcementModes(value)
+	if err != nil {
+		glog.Errorf("ignoring invalid %s annotation %q of namespace %s: %v", enforcementModeAnnotationKey, value, namespace, err)
+		return EnforcementModes{}
+	}
+	return modes
+}
+
+// enforcementMode returns the mode of the check in the namespace. The namespace
+// annotation takes precedence over the global modes, and a mode set for the check
+// takes precedence over the default mode.
+func enforcementMode(check, namespace string) EnforcementMode {
+	nsModes := namespaceEnforcementModes(namespace)
+	if mode, ok := nsModes.Checks[check]; ok {
+		return mode
+	}
+	if nsModes.Default != "" {
+		return nsModes.Default
+	}
+	if mode, ok := enforcementModes.Checks[check]; ok {
+		return mode
+	}
+	return enforcementModes.Default
+}
+
+// enforce applies the enforcement mode of the check to its failure. It returns
+// true in enforce mode, appends the failure to the warnings in warn mode and
+// only logs it in audit mode.
+func enforce(failure CheckFailure, namespace string, warnings *[]string) bool {
+	mode := enforcementMode(failure.Check, namespace)
+	localmetrics.IncAdmissionCheckFailures(failure.Check, string(mode))
+
+	switch mode {
+	case EnforcementModeWarn:
+		glog.Infof("check %s failed in namespace %s, warning only: %s", failure.Check, namespace, failure.Message())
+		*warnings = append(*warnings, failure.Message())
+		return false
+	case EnforcementModeAudit:
+		glog.Infof("check %s failed in namespace %s, audit only: %s", failure.Check, namespace, failure.Message())
+		return false
+	}
+	return true
+}
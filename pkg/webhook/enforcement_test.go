@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// postAdmissionReview sends the object to the handler and returns the response
+func postAdmissionReview(handler func(w http.ResponseWriter, req *http.Request), namespace string, operation admissionv1.Operation, obj interface{}) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(obj)
+	Expect(err).NotTo(HaveOccurred())
+	body, err := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "1234",
+			Namespace: namespace,
+			Operation: operation,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	req := httptest.NewRequest("POST", "https://fakewebhook/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	ar := admissionv1.AdmissionReview{}
+	Expect(json.Unmarshal(w.Body.Bytes(), &ar)).To(Succeed())
+	Expect(ar.Response).NotTo(BeNil())
+	return ar.Response
+}
+
+var _ = Describe("Enforcement modes", func() {
+	AfterEach(func() {
+		SetEnforcementModes(EnforcementModes{})
+		SetQuotas(Quotas{})
+		resetPolicies()
+	})
+
+	DescribeTable("parsing",
+		func(value string, expected EnforcementModes) {
+			modes, err := ParseEnforcementModes(value)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(modes).To(Equal(expected))
+		},
+		Entry("a default mode", "warn", EnforcementModes{Default: EnforcementModeWarn, Checks: map[string]EnforcementMode{}}),
+		Entry("check modes", "policy=audit, quota=warn", EnforcementModes{Checks: map[string]EnforcementMode{
+			CheckPolicy: EnforcementModeAudit,
+			CheckQuota:  EnforcementModeWarn,
+		}}),
+		Entry("both", "warn,config=enforce", EnforcementModes{Default: EnforcementModeWarn, Checks: map[string]EnforcementMode{
+			CheckConfig: EnforcementModeEnforce,
+		}}),
+	)
+
+	DescribeTable("parsing invalid values",
+		func(value, message string) {
+			_, err := ParseEnforcementModes(value)
+			Expect(err).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("unknown mode", "dryrun", `invalid enforcement mode "dryrun"`),
+		Entry("unknown check", "sriov=warn", `unknown check "sriov"`),
+		Entry("unknown check mode", "quota=off", `invalid enforcement mode "off"`),
+	)
+
+	Describe("selecting the mode", func() {
+		BeforeEach(func() {
+			SetEnforcementModes(EnforcementModes{Checks: map[string]EnforcementMode{CheckTuning: EnforcementModeAudit}})
+			canary := newNamespace("canary", nil)
+			canary.Annotations = map[string]string{enforcementModeAnnotationKey: "warn,quota=enforce"}
+			broken := newNamespace("broken", nil)
+			broken.Annotations = map[string]string{enforcementModeAnnotationKey: "later"}
+			setPolicies([]*v1.Namespace{canary, broken})
+		})
+
+		It("should use the global modes", func() {
+			Expect(enforcementMode(CheckTuning, "other")).To(Equal(EnforcementModeAudit))
+			Expect(enforcementMode(CheckPolicy, "other")).To(Equal(EnforcementModeEnforce))
+		})
+
+		It("should prefer the namespace annotation", func() {
+			Expect(enforcementMode(CheckTuning, "canary")).To(Equal(EnforcementModeWarn))
+			Expect(enforcementMode(CheckQuota, "canary")).To(Equal(EnforcementModeEnforce))
+		})
+
+		It("should ignore an invalid annotation", func() {
+			Expect(enforcementMode(CheckTuning, "broken")).To(Equal(EnforcementModeAudit))
+		})
+	})
+
+	Describe("validating requests", func() {
+		var pod v1.Pod
+
+		BeforeEach(func() {
+			SetQuotas(Quotas{MaxNetworksPerPod: 1})
+			pod = newPod(map[string]string{networksAnnotationKey: "net1,net2"})
+		})
+
+		It("should deny in enforce mode", func() {
+			resp := postAdmissionReview(IsolateHandler, "tenant", admissionv1.Create, pod)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Message).To(ContainSubstring("pod requests 2 networks"))
+		})
+
+		It("should allow with a warning in warn mode", func() {
+			SetEnforcementModes(EnforcementModes{Checks: map[string]EnforcementMode{CheckQuota: EnforcementModeWarn}})
+			resp := postAdmissionReview(IsolateHandler, "tenant", admissionv1.Create, pod)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Warnings).To(ConsistOf(ContainSubstring("pod requests 2 networks")))
+		})
+
+		It("should allow silently in audit mode", func() {
+			SetEnforcementModes(EnforcementModes{Default: EnforcementModeAudit})
+			resp := postAdmissionReview(IsolateHandler, "tenant", admissionv1.Create, pod)
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Warnings).To(BeEmpty())
+		})
+
+		It("should collect the warnings of every check", func() {
+			SetEnforcementModes(EnforcementModes{Default: EnforcementModeWarn})
+			resp := postAdmissionReview(ValidateHandler, "tenant", admissionv1.Create, newNetAttachDef(`{"cniVersion": "0.3.1"}`))
+			Expect(resp.Allowed).To(BeTrue())
+			Expect(resp.Warnings).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+var dns1123LabelRegexp = regexp.MustCompile("^[a-z0-9]([-a-z0-9]*[a-z0-9])?$")
+
+// Seed corpora, taken from the tests of this package. The fuzz targets only
+// assert that the functions do not panic and return consistent results, they
+// run on the seeds with go test and explore further with go test -fuzz.
+
+var networkAnnotationSeeds = []string{
+	"",
+	"net1",
+	"net1,net2",
+	"net1,net2@eth2",
+	"a@net1,b@net2",
+	"other/net1",
+	"infra/vf-pool",
+	"a/b/c",
+	"a@b@c",
+	"a,b,c",
+	"Net1",
+	" net1 , other/net2@eth1 ",
+	`[{"name": "net1"}]`,
+	`[{"name": "net1", "namespace": "other"}]`,
+	`[{"name": "net1", "interface": "eth1", "ips": ["10.0.0.1/24"]}]`,
+	`[null]`,
+	`null`,
+	`{"name": "net1"}`,
+}
+
+var cniConfigSeeds = []string{
+	``,
+	`{"some-invalid": "config"}`,
+	`{"cniVersion": "0.3.0", "type": "some-plugin"}`,
+	`{"cniVersion": "0.3.1", "name": "net", "plugins": 5}`,
+	`{"cniVersion": "0.3.1", "name": "net", "plugins": []}`,
+	`{"cniVersion": "0.3.1", "name": "net", "plugins": [null, 1, "x", {}]}`,
+	`{"type": "bridge" "name": "x"}`,
+	`{"cniVersion": "0.3.0", "name": "some-bridge-network", "plugins": [
+		{"type": "bridge", "bridge": "br0", "ipam": {"type": "host-local", "subnet": "192.168.1.0/24"}},
+		{"type": "some-plugin"},
+		{"type": "another-plugin", "sysctl": {"net.ipv4.conf.all.log_martians": "1"}}]}`,
+	`{"cniVersion": "0.3.1", "name": "net", "plugins": [{"type": "tuning", "ipam": {"type": "host-local", "subnet": "10.1.0.0/33"}}]}`,
+	`{"cniVersion": "0.3.1", "type": "bridge", "ipam": {"type": "host-local", "ranges": [[{"subnet": "10.0.0.0/24"}], [{"subnet": 5}], 7]}}`,
+	`{"cniVersion": "0.3.1", "type": "bridge", "ipam": "host-local"}`,
+	`{"cniVersion": "0.4.0", "type": "tuning", "mtu": 9000, "promisc": true, "sysctl": {"net.core.somaxconn": "500"}}`,
+	`[]`,
+	`null`,
+}
+
+var admissionReviewSeeds = []string{
+	"fake-body",
+	"{}",
+	`{"kind": "AdmissionReview"`,
+	`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`,
+	`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview", "request": {"uid": "1234", "operation": "CREATE", "object": {"metadata": {"name": "net1"}}}}`,
+	`{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "request": {"uid": "1234", "operation": "UPDATE", "object": null, "oldObject": {}}}`,
+	`{"apiVersion": "admission.k8s.io/v1beta1", "kind": "AdmissionReview", "response": {"uid": "1234", "allowed": true, "patchType": "JSONPatch"}}`,
+	`{"apiVersion": "admission.k8s.io/v2", "kind": "AdmissionReview"}`,
+	`{"apiVersion": "v1", "kind": "Pod"}`,
+}
+
+func FuzzParsePodNetworkAnnotation(f *testing.F) {
+	for _, seed := range networkAnnotationSeeds {
+		f.Add(seed, "default")
+	}
+	f.Fuzz(func(t *testing.T, annotation, namespace string) {
+		networks, err := parsePodNetworkAnnotation(annotation, namespace)
+		if err != nil {
+			return
+		}
+		for i, network := range networks {
+			if network == nil {
+				t.Fatalf("network %d of %q is nil", i, annotation)
+			}
+			if network.Namespace == "" && namespace != "" {
+				t.Fatalf("network %d of %q has no namespace", i, annotation)
+			}
+		}
+	})
+}
+
+func FuzzParsePodNetworkObjectName(f *testing.F) {
+	for _, seed := range networkAnnotationSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		namespace, network, ifName, err := parsePodNetworkObjectName(name)
+		if err != nil {
+			return
+		}
+		for _, item := range []string{namespace, network, ifName} {
+			if item != "" && !dns1123LabelRegexp.MatchString(item) {
+				t.Fatalf("parsing %q returned invalid item %q", name, item)
+			}
+		}
+	})
+}
+
+func FuzzValidateCNIConfig(f *testing.F) {
+	for _, seed := range cniConfigSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, config []byte) {
+		validateCNIConfig(config)
+		getCNIPlugins(config)
+	})
+}
+
+func FuzzDeserializeAdmissionReview(f *testing.F) {
+	for _, seed := range admissionReviewSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		ar, err := deserializeAdmissionReview(body)
+		if err != nil {
+			return
+		}
+		if ar == nil {
+			t.Fatalf("no AdmissionReview and no error for %q", body)
+		}
+		// the response must be writable in the version of the request
+		if prepareAdmissionReviewResponse(true, "", ar) == nil {
+			reviewForVersion(ar)
+		}
+	})
+}
+
+func FuzzAdmissionHandlers(f *testing.F) {
+	for _, seed := range admissionReviewSeeds {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, handler := range []http.HandlerFunc{ValidateHandler, IsolateHandler} {
+			req := httptest.NewRequest("POST", "https://fakewebhook/validate", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			handler(httptest.NewRecorder(), req)
+		}
+	})
+}
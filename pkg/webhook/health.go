@@ -0,0 +1,127 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"k8s.io/client-go/tools/cache"
+)
+
+// HealthCheck is a named check of a health endpoint such as /livez or /readyz
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// NewHealthCheck returns a check ignoring the context of the request
+func NewHealthCheck(name string, check func() error) HealthCheck {
+	return HealthCheck{Name: name, Check: func(context.Context) error { return check() }}
+}
+
+// HealthHandler runs the checks on each request. It answers ok if they all
+// pass and 503 Service Unavailable listing each check otherwise, or when the
+// verbose query parameter is set, as the API server health endpoints do.
+func HealthHandler(endpoint string, checks ...HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var output bytes.Buffer
+		failed := false
+		for _, check := range checks {
+			if err := check.Check(req.Context()); err != nil {
+				failed = true
+				glog.V(2).Infof("%s check %s failed: %v", endpoint, check.Name, err)
+				fmt.Fprintf(&output, "[-]%s failed: %v\n", check.Name, err)
+				continue
+			}
+			fmt.Fprintf(&output, "[+]%s ok\n", check.Name)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if failed {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(&output, "%s check failed\n", endpoint)
+			w.Write(output.Bytes())
+			return
+		}
+		if _, verbose := req.URL.Query()["verbose"]; verbose {
+			fmt.Fprintf(&output, "%s check passed\n", endpoint)
+			w.Write(output.Bytes())
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+}
+
+// informerSynced returns an error until the informer is started and synced, or
+// once it is stopped
+func informerSynced(what string, informer cache.SharedIndexInformer) error {
+	switch {
+	case informer == nil:
+		return fmt.Errorf("%s informer not started", what)
+	case informer.IsStopped():
+		return fmt.Errorf("%s informer stopped", what)
+	case !informer.HasSynced():
+		return fmt.Errorf("%s cache not synced", what)
+	}
+	return nil
+}
+
+// InformersSynced returns an error until the namespace and net-attach-def
+// caches are synced
+func InformersSynced() error {
+	if err := informerSynced("namespace", namespaceInformer); err != nil {
+		return err
+	}
+	return informerSynced("net-attach-def", netAttachDefInformer)
+}
+
+// PoliciesSynced returns an error until the network attachment policy cache is synced
+func PoliciesSynced() error {
+	return informerSynced("network attachment policy", policyInformer)
+}
+
+// ConfigFilesLoaded returns an error until the watched rules and deprecations
+// files are loaded. Failed reloads keep the previous configuration in use, and
+// are only reported by the config_last_reload_successful metric.
+func ConfigFilesLoaded() error {
+	loadedConfigFilesMutex.Lock()
+	defer loadedConfigFilesMutex.Unlock()
+	var missing []string
+	for what, loaded := range loadedConfigFiles {
+		if !loaded {
+			missing = append(missing, what)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("%s not loaded", strings.Join(missing, " and "))
+	}
+	return nil
+}
+
+// APIServerReachable returns an error if the API server does not answer
+func APIServerReachable(ctx context.Context) error {
+	if err := clientset.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error(); err != nil {
+		return fmt.Errorf("API server not reachable: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("Health checks", func() {
+	var ready error
+
+	get := func(handler http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		return w
+	}
+
+	newHandler := func() http.Handler {
+		return HealthHandler("readyz",
+			NewHealthCheck("ping", func() error { return nil }),
+			NewHealthCheck("certificate", func() error { return ready }))
+	}
+
+	BeforeEach(func() {
+		ready = nil
+	})
+
+	It("should answer ok when all checks pass", func() {
+		w := get(newHandler(), "/readyz")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("ok"))
+	})
+
+	It("should list each check when verbose", func() {
+		w := get(newHandler(), "/readyz?verbose")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("[+]ping ok\n[+]certificate ok\nreadyz check passed\n"))
+	})
+
+	It("should list each check when one fails", func() {
+		ready = fmt.Errorf("no certificate loaded")
+		w := get(newHandler(), "/readyz")
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(w.Body.String()).To(Equal("[+]ping ok\n[-]certificate failed: no certificate loaded\nreadyz check failed\n"))
+	})
+
+	It("should report the informers and policies as synced until they are stopped", func() {
+		Expect(InformersSynced()).To(MatchError(ContainSubstring("not started")))
+		Expect(PoliciesSynced()).To(MatchError(ContainSubstring("not started")))
+
+		newInformer := func() cache.SharedIndexInformer {
+			return cache.NewSharedIndexInformer(&cache.ListWatch{
+				ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+					return &v1.NamespaceList{}, nil
+				},
+				WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+					return watch.NewFake(), nil
+				},
+			}, &v1.Namespace{}, 0, cache.Indexers{})
+		}
+		namespaceInformer, netAttachDefInformer, policyInformer = newInformer(), newInformer(), newInformer()
+		defer func() {
+			namespaceInformer, netAttachDefInformer, policyInformer = nil, nil, nil
+		}()
+		Expect(InformersSynced()).To(MatchError(ContainSubstring("not synced")))
+
+		stop := make(chan struct{})
+		for _, informer := range []cache.SharedIndexInformer{namespaceInformer, netAttachDefInformer, policyInformer} {
+			go informer.Run(stop)
+		}
+		Eventually(InformersSynced, 5*time.Second).Should(Succeed())
+		Eventually(PoliciesSynced, 5*time.Second).Should(Succeed())
+
+		close(stop)
+		Eventually(InformersSynced, 5*time.Second).Should(MatchError(ContainSubstring("stopped")))
+		Eventually(PoliciesSynced, 5*time.Second).Should(MatchError(ContainSubstring("stopped")))
+	})
+
+	It("should report the watched configuration files until they are loaded", func() {
+		Expect(ConfigFilesLoaded()).To(Succeed())
+
+		loadedConfigFilesMutex.Lock()
+		loadedConfigFiles["rules"] = false
+		loadedConfigFiles["deprecations"] = true
+		loadedConfigFilesMutex.Unlock()
+		defer func() {
+			loadedConfigFilesMutex.Lock()
+			loadedConfigFiles = map[string]bool{}
+			loadedConfigFilesMutex.Unlock()
+		}()
+		Expect(ConfigFilesLoaded()).To(MatchError("rules not loaded"))
+
+		configFileLoaded("rules")
+		Expect(ConfigFilesLoaded()).To(Succeed())
+	})
+
+	It("should check that the API server answers", func() {
+		status := http.StatusOK
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"major": "1", "minor": "30"}`))
+		}))
+		defer server.Close()
+		oldClient := clientset
+		defer func() { clientset = oldClient }()
+		var err error
+		clientset, err = kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(APIServerReachable(context.Background())).To(Succeed())
+		status = http.StatusServiceUnavailable
+		Expect(APIServerReachable(context.Background())).To(MatchError(ContainSubstring("API server not reachable")))
+	})
+})
@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/golang/glog"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	// namespaceIndexer caches namespaces so that their labels and annotations can be read
+	namespaceIndexer cache.Indexer
+	// netAttachDefIndexer caches net-attach-defs, indexed by namespace
+	netAttachDefIndexer cache.Indexer
+
+	// namespaceInformer and netAttachDefInformer fill the caches, they are
+	// kept for the health checks
+	namespaceInformer    cache.SharedIndexInformer
+	netAttachDefInformer cache.SharedIndexInformer
+)
+
+// StartInformers starts the informers for namespaces and net-attach-defs
+// and waits for their caches to be synced
+func StartInformers(stopCh <-chan struct{}) error {
+	namespaceInformer = cache.NewSharedIndexInformer(
+		cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), "namespaces", v1.NamespaceAll, fields.Everything()),
+		&v1.Namespace{},
+		0,
+		cache.Indexers{},
+	)
+
+	netAttachDefInformer = cache.NewSharedIndexInformer(
+		cache.NewListWatchFromClient(nadClientset.K8sCniCncfIoV1().RESTClient(), "network-attachment-definitions", v1.NamespaceAll, fields.Everything()),
+		&netv1.NetworkAttachmentDefinition{},
+		0,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+	)
+
+	go namespaceInformer.Run(stopCh)
+	go netAttachDefInformer.Run(stopCh)
+
+	if !cache.WaitForCacheSync(stopCh, namespaceInformer.HasSynced, netAttachDefInformer.HasSynced) {
+		return fmt.Errorf("timed out waiting for caches to sync")
+	}
+
+	namespaceIndexer = namespaceInformer.GetIndexer()
+	netAttachDefIndexer = netAttachDefInformer.GetIndexer()
+	glog.Infof("namespace and net-attach-def caches synced")
+	return nil
+}
+
+// getNamespace returns the cached namespace, nil if unknown
+func getNamespace(name string) *v1.Namespace {
+	if namespaceIndexer == nil {
+		return nil
+	}
+	obj, exists, err := namespaceIndexer.GetByKey(name)
+	if err != nil || !exists {
+		return nil
+	}
+	return obj.(*v1.Namespace)
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	// DefaultAuthAllowedTTL is how long authenticated tokens and allowed
+	// decisions are cached, as by the API server webhook authorizer
+	DefaultAuthAllowedTTL = 5 * time.Minute
+	// DefaultAuthDeniedTTL is how long rejected tokens and denied decisions are cached
+	DefaultAuthDeniedTTL = 30 * time.Second
+
+	// authCacheSize is the number of tokens and decisions cached
+	authCacheSize = 1024
+)
+
+// DelegatedAuth configures the authentication of bearer tokens with
+// TokenReviews and the authorization of the requests with
+// SubjectAccessReviews on their non-resource URL, as kube-rbac-proxy does
+type DelegatedAuth struct {
+	// AllowedTTL is how long authenticated tokens and allowed decisions are cached
+	AllowedTTL time.Duration
+	// DeniedTTL is how long rejected tokens and denied decisions are cached
+	DeniedTTL time.Duration
+}
+
+// delegatedAuthorizer holds the cached tokens and decisions
+type delegatedAuthorizer struct {
+	DelegatedAuth
+	// tokens are the users of the tokens by their hash, nil if the token was rejected
+	tokens *cache.LRUExpireCache
+	// decisions are whether a user is allowed a verb on a path
+	decisions *cache.LRUExpireCache
+}
+
+// ttl returns how long a result is cached
+func (auth *delegatedAuthorizer) ttl(allowed bool) time.Duration {
+	if allowed {
+		return auth.AllowedTTL
+	}
+	return auth.DeniedTTL
+}
+
+// authenticate returns the user of the token, or nil if it is not valid
+func (auth *delegatedAuthorizer) authenticate(ctx context.Context, token string) (*authenticationv1.UserInfo, error) {
+	key := sha256.Sum256([]byte(token))
+	if user, ok := auth.tokens.Get(key); ok {
+		return user.(*authenticationv1.UserInfo), nil
+	}
+
+	review, err := clientset.AuthenticationV1().TokenReviews().Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error creating token review: %v", err)
+	}
+	var user *authenticationv1.UserInfo
+	if review.Status.Authenticated {
+		user = &review.Status.User
+	} else if review.Status.Error != "" {
+		glog.V(4).Infof("rejecting metrics request: %s", review.Status.Error)
+	}
+	auth.tokens.Add(key, user, auth.ttl(user != nil))
+	return user, nil
+}
+
+// authorize tells whether the user is allowed the verb on the non-resource URL
+func (auth *delegatedAuthorizer) authorize(ctx context.Context, user *authenticationv1.UserInfo, verb, path string) (bool, error) {
+	key := fmt.Sprintf("%q %q %q %q %q", user.Username, user.UID, strings.Join(user.Groups, ","), verb, path)
+	if allowed, ok := auth.decisions.Get(key); ok {
+		return allowed.(bool), nil
+	}
+
+	extra := map[string]authorizationv1.ExtraValue{}
+	for name, values := range user.Extra {
+		extra[name] = authorizationv1.ExtraValue(values)
+	}
+	review, err := clientset.AuthorizationV1().SubjectAccessReviews().Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("error creating subject access review: %v", err)
+	}
+	allowed := review.Status.Allowed && !review.Status.Denied
+	auth.decisions.Add(key, allowed, auth.ttl(allowed))
+	return allowed, nil
+}
+
+// DelegateAuth only lets requests through with a bearer token of a user
+// allowed the request verb on the request path. Errors reaching the API server
+// are not cached and answered with 500 Internal Server Error.
+func DelegateAuth(config DelegatedAuth) Middleware {
+	auth := &delegatedAuthorizer{
+		DelegatedAuth: config,
+		tokens:        cache.NewLRUExpireCache(authCacheSize),
+		decisions:     cache.NewLRUExpireCache(authCacheSize),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			user, err := auth.authenticate(req.Context(), token)
+			if err != nil {
+				glog.Errorf("error authenticating %s request: %v", req.URL.Path, err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if user == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			// the API server authorizes non-resource URLs with the lower case HTTP method
+			verb := strings.ToLower(req.Method)
+			allowed, err := auth.authorize(req.Context(), user, verb, req.URL.Path)
+			if err != nil {
+				glog.Errorf("error authorizing %s request of %s: %v", req.URL.Path, user.Username, err)
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				glog.V(4).Infof("forbidding %s %s to %s", verb, req.URL.Path, user.Username)
+				http.Error(w, fmt.Sprintf("user %q is not allowed to %s %s", user.Username, verb, req.URL.Path), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
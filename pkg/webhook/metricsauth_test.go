@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var _ = Describe("Delegated metrics authentication", func() {
+	var (
+		handler    http.Handler
+		fakeClient *fake.Clientset
+		oldClient  kubernetes.Interface
+		// tokens are the users of the valid tokens
+		tokens map[string]string
+		// allowed are the users allowed to get /metrics
+		allowed       map[string]bool
+		reviews       []string
+		failReviews   bool
+		accessReviews []authorizationv1.SubjectAccessReviewSpec
+	)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "https://webhook:9091"+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	BeforeEach(func() {
+		tokens = map[string]string{"prometheus-token": "system:serviceaccount:monitoring:prometheus", "other-token": "other"}
+		allowed = map[string]bool{"system:serviceaccount:monitoring:prometheus": true}
+		reviews = nil
+		accessReviews = nil
+		failReviews = false
+
+		fakeClient = fake.NewSimpleClientset()
+		fakeClient.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if failReviews {
+				return true, nil, fmt.Errorf("connection refused")
+			}
+			review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+			reviews = append(reviews, review.Spec.Token)
+			if user, ok := tokens[review.Spec.Token]; ok {
+				review.Status.Authenticated = true
+				review.Status.User = authenticationv1.UserInfo{Username: user, Groups: []string{"system:authenticated"}}
+			}
+			return true, review, nil
+		})
+		fakeClient.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+			accessReviews = append(accessReviews, review.Spec)
+			review.Status.Allowed = allowed[review.Spec.User] && review.Spec.NonResourceAttributes.Path == "/metrics"
+			return true, review, nil
+		})
+		oldClient = clientset
+		clientset = fakeClient
+
+		handler = DelegateAuth(DelegatedAuth{AllowedTTL: DefaultAuthAllowedTTL, DeniedTTL: DefaultAuthDeniedTTL})(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("metrics")) }))
+	})
+
+	AfterEach(func() {
+		clientset = oldClient
+	})
+
+	It("should reject requests without a valid bearer token", func() {
+		w := get("/metrics", "")
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(w.Header().Get("WWW-Authenticate")).To(HavePrefix("Bearer"))
+		Expect(get("/metrics", "invalid-token").Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should authorize the users on the non-resource URL", func() {
+		w := get("/metrics", "prometheus-token")
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("metrics"))
+		Expect(accessReviews).To(HaveLen(1))
+		Expect(accessReviews[0].User).To(Equal("system:serviceaccount:monitoring:prometheus"))
+		Expect(accessReviews[0].Groups).To(ConsistOf("system:authenticated"))
+		Expect(*accessReviews[0].NonResourceAttributes).To(Equal(authorizationv1.NonResourceAttributes{Path: "/metrics", Verb: "get"}))
+
+		w = get("/metrics", "other-token")
+		Expect(w.Code).To(Equal(http.StatusForbidden))
+		Expect(w.Body.String()).To(ContainSubstring(`user "other" is not allowed to get /metrics`))
+		Expect(get("/other", "prometheus-token").Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("should cache the tokens and decisions", func() {
+		for i := 0; i < 3; i++ {
+			Expect(get("/metrics", "prometheus-token").Code).To(Equal(http.StatusOK))
+			Expect(get("/metrics", "other-token").Code).To(Equal(http.StatusForbidden))
+			Expect(get("/metrics", "invalid-token").Code).To(Equal(http.StatusUnauthorized))
+		}
+		Expect(reviews).To(ConsistOf("prometheus-token", "other-token", "invalid-token"))
+		Expect(accessReviews).To(HaveLen(2))
+	})
+
+	It("should not cache errors", func() {
+		failReviews = true
+		Expect(get("/metrics", "prometheus-token").Code).To(Equal(http.StatusInternalServerError))
+		failReviews = false
+		Expect(get("/metrics", "prometheus-token").Code).To(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,139 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/golang/glog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultMaxRequestBodyBytes fits an AdmissionReview of an update, which holds
+	// both the old and the new object of up to 3MB each
+	DefaultMaxRequestBodyBytes = 8 << 20
+	// DefaultRequestTimeout matches the default timeoutSeconds of webhook configurations
+	DefaultRequestTimeout = 10 * time.Second
+)
+
+// Middleware wraps a handler
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps the handler with the middlewares, the first one is the outermost
+func Chain(handler http.Handler, middlewares ...Middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// LimitBodySize fails reading request bodies larger than maxBytes, which
+// readAdmissionReview reports as 413 Request Entity Too Large
+func LimitBodySize(maxBytes int64) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			req.Body = http.MaxBytesReader(w, req.Body, maxBytes)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// Timeout sets a deadline on the request context and replies 503 Service
+// Unavailable if the handler does not finish in time
+func Timeout(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, timeout, "admission request timed out")
+	}
+}
+
+// recorderWriter records whether the response was started
+type recorderWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *recorderWriter) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recorderWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(data)
+}
+
+// RecoverPanics turns a panic of the handler into a denied AdmissionReview with
+// a meaningful message. The request is rejected whatever the failure policy of
+// the webhook, which only applies when the call itself fails.
+func RecoverPanics() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body []byte
+			if req.Body != nil {
+				data, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					http.Error(w, fmt.Sprintf("error reading HTTP request: %v", err), readErrorStatus(err))
+					return
+				}
+				body = data
+				req.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			rw := &recorderWriter{ResponseWriter: w}
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				glog.Errorf("panic serving %s: %v\n%s", req.URL.Path, r, debug.Stack())
+				if rw.written {
+					return
+				}
+				ar, err := deserializeAdmissionReview(body)
+				if err != nil || prepareAdmissionReviewResponse(false, "", ar) != nil {
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+				ar.Response.Result = &metav1.Status{
+					Status:  metav1.StatusFailure,
+					Reason:  metav1.StatusReasonInternalError,
+					Code:    http.StatusInternalServerError,
+					Message: fmt.Sprintf("internal error validating the request: %v", r),
+				}
+				writeResponse(w, ar)
+			}()
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// readErrorStatus returns the HTTP status for an error reading the request body
+func readErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// serve sends the body to the handler and returns the recorded response
+func serve(handler http.Handler, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "https://fakewebhook/validate", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+var _ = Describe("Middleware", func() {
+	var body []byte
+
+	BeforeEach(func() {
+		body = reviewBody("admission.k8s.io/v1", newPod(nil))
+	})
+
+	It("should apply the middlewares outermost first", func() {
+		var order []string
+		record := func(name string) Middleware {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					order = append(order, name)
+					next.ServeHTTP(w, req)
+				})
+			}
+		}
+		handler := Chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { order = append(order, "handler") }),
+			record("outer"), record("inner"))
+		serve(handler, body)
+		Expect(order).To(Equal([]string{"outer", "inner", "handler"}))
+	})
+
+	Describe("limiting the body size", func() {
+		It("should reject larger requests", func() {
+			w := serve(Chain(http.HandlerFunc(IsolateHandler), LimitBodySize(64)), body)
+			Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		})
+
+		It("should accept smaller requests", func() {
+			w := serve(Chain(http.HandlerFunc(IsolateHandler), LimitBodySize(DefaultMaxRequestBodyBytes)), body)
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		It("should reject larger requests before recovering panics", func() {
+			w := serve(Chain(http.HandlerFunc(IsolateHandler), LimitBodySize(64), RecoverPanics()), body)
+			Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+		})
+	})
+
+	Describe("recovering panics", func() {
+		panicking := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if _, _, err := readAdmissionReview(req); err != nil {
+				Fail(err.Error())
+			}
+			panic("something went wrong")
+		})
+
+		It("should deny the request", func() {
+			w := serve(Chain(panicking, RecoverPanics()), body)
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			ar := admissionv1.AdmissionReview{}
+			Expect(json.Unmarshal(w.Body.Bytes(), &ar)).To(Succeed())
+			Expect(string(ar.Response.UID)).To(Equal("5678"))
+			Expect(ar.Response.Allowed).To(BeFalse())
+			Expect(ar.Response.Result.Reason).To(Equal(metav1.StatusReasonInternalError))
+			Expect(ar.Response.Result.Message).To(ContainSubstring("something went wrong"))
+		})
+
+		It("should recover panics behind the timeout", func() {
+			w := serve(Chain(panicking, RecoverPanics(), Timeout(time.Second)), body)
+			ar := admissionv1.AdmissionReview{}
+			Expect(json.Unmarshal(w.Body.Bytes(), &ar)).To(Succeed())
+			Expect(ar.Response.Result.Reason).To(Equal(metav1.StatusReasonInternalError))
+		})
+
+		It("should fail requests that are not AdmissionReviews", func() {
+			handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("something went wrong") })
+			w := serve(Chain(handler, RecoverPanics()), []byte(`{"kind": "AdmissionReview"`))
+			Expect(w.Code).To(Equal(http.StatusInternalServerError))
+		})
+	})
+
+	Describe("timeouts", func() {
+		It("should set a deadline on the request", func() {
+			var deadline time.Time
+			var ok bool
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				deadline, ok = req.Context().Deadline()
+			})
+			serve(Chain(handler, Timeout(time.Minute)), body)
+			Expect(ok).To(BeTrue())
+			Expect(deadline).To(BeTemporally("~", time.Now().Add(time.Minute), 5*time.Second))
+		})
+
+		It("should reply when the handler does not finish in time", func() {
+			handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				<-req.Context().Done()
+			})
+			w := serve(Chain(handler, Timeout(10*time.Millisecond)), body)
+			Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(strings.TrimSpace(w.Body.String())).To(Equal("admission request timed out"))
+		})
+	})
+})
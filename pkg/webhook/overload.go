@@ -0,0 +1,132 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+)
+
+// OverloadBehavior defines how requests are answered when the webhook is overloaded
+type OverloadBehavior string
+
+const (
+	// OverloadReject replies 429 Too Many Requests, the API server then applies
+	// the failure policy of the webhook configuration
+	OverloadReject OverloadBehavior = "reject"
+	// OverloadAllow admits the request without validating it and returns a warning
+	OverloadAllow OverloadBehavior = "allow"
+
+	// DefaultQueueTimeout leaves time to handle a queued request within the
+	// default timeoutSeconds of webhook configurations
+	DefaultQueueTimeout = 5 * time.Second
+
+	shedReasonQueueFull    = "queue-full"
+	shedReasonQueueTimeout = "queue-timeout"
+	shedReasonCanceled     = "canceled"
+
+	overloadWarning = "the net-attach-def admission webhook is overloaded, the request was admitted without validation"
+)
+
+// InFlightLimits bounds the number of admission requests handled concurrently
+type InFlightLimits struct {
+	// MaxInFlight is the number of requests handled concurrently
+	MaxInFlight int
+	// MaxQueued is the number of requests waiting for one of the others to finish
+	MaxQueued int
+	// QueueTimeout is how long a request waits in the queue
+	QueueTimeout time.Duration
+	// Behavior applies to the requests that do not fit in the queue or time out
+	Behavior OverloadBehavior
+}
+
+// ParseOverloadBehavior checks the name of an overload behavior
+func ParseOverloadBehavior(value string) (OverloadBehavior, error) {
+	switch behavior := OverloadBehavior(value); behavior {
+	case OverloadReject, OverloadAllow:
+		return behavior, nil
+	}
+	return "", fmt.Errorf("invalid overload behavior %q, must be one of reject, allow", value)
+}
+
+// LimitInFlight handles at most limits.MaxInFlight requests at a time and queues
+// up to limits.MaxQueued more. Requests that do not fit in the queue, or wait
+// longer than limits.QueueTimeout, are shed according to limits.Behavior.
+func LimitInFlight(limits InFlightLimits) Middleware {
+	slots := make(chan struct{}, limits.MaxInFlight)
+	var queued int64
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			select {
+			case slots <- struct{}{}:
+			default:
+				if atomic.AddInt64(&queued, 1) > int64(limits.MaxQueued) {
+					atomic.AddInt64(&queued, -1)
+					shedRequest(w, req, limits.Behavior, shedReasonQueueFull)
+					return
+				}
+				localmetrics.SetAdmissionQueueDepth(int(atomic.LoadInt64(&queued)))
+
+				reason := ""
+				timer := time.NewTimer(limits.QueueTimeout)
+				select {
+				case slots <- struct{}{}:
+				case <-timer.C:
+					reason = shedReasonQueueTimeout
+				case <-req.Context().Done():
+					reason = shedReasonCanceled
+				}
+				timer.Stop()
+				localmetrics.SetAdmissionQueueDepth(int(atomic.AddInt64(&queued, -1)))
+				if reason != "" {
+					shedRequest(w, req, limits.Behavior, reason)
+					return
+				}
+			}
+			defer func() { <-slots }()
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// shedRequest answers a request without handling it
+func shedRequest(w http.ResponseWriter, req *http.Request, behavior OverloadBehavior, reason string) {
+	localmetrics.IncAdmissionShedRequests(reason, string(behavior))
+	glog.Warningf("overloaded, shedding request to %s (%s): %s", req.URL.Path, reason, behavior)
+
+	if behavior == OverloadAllow {
+		ar, httpStatus, err := readAdmissionReview(req)
+		if err != nil {
+			http.Error(w, err.Error(), httpStatus)
+			return
+		}
+		if err := prepareAdmissionReviewResponse(true, "", ar); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ar.Response.Warnings = []string{overloadWarning}
+		writeResponse(w, ar)
+		return
+	}
+
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "too many admission requests", http.StatusTooManyRequests)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	dto "github.com/prometheus/client_model/go"
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// shedRequests returns the value of the shed requests counter
+func shedRequests(reason string, behavior OverloadBehavior) float64 {
+	metric := &dto.Metric{}
+	Expect(localmetrics.AdmissionShedRequests.WithLabelValues(reason, string(behavior)).Write(metric)).To(Succeed())
+	return metric.GetCounter().GetValue()
+}
+
+// queueDepth returns the value of the queue depth gauge
+func queueDepth() float64 {
+	metric := &dto.Metric{}
+	Expect(localmetrics.AdmissionQueueDepth.Write(metric)).To(Succeed())
+	return metric.GetGauge().GetValue()
+}
+
+var _ = Describe("Overload protection", func() {
+	var (
+		body    []byte
+		release chan struct{}
+		started chan struct{}
+		blocked http.Handler
+		wg      sync.WaitGroup
+	)
+
+	BeforeEach(func() {
+		body = reviewBody("admission.k8s.io/v1", newPod(nil))
+		release = make(chan struct{})
+		started = make(chan struct{}, 10)
+		blocked = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			started <- struct{}{}
+			<-release
+			IsolateHandler(w, req)
+		})
+	})
+
+	AfterEach(func() {
+		close(release)
+		wg.Wait()
+		localmetrics.AdmissionShedRequests.Reset()
+		localmetrics.SetAdmissionQueueDepth(0)
+	})
+
+	// serveAsync serves the request in the background
+	serveAsync := func(handler http.Handler) chan *httptest.ResponseRecorder {
+		ch := make(chan *httptest.ResponseRecorder, 1)
+		wg.Add(1)
+		go func() {
+			defer GinkgoRecover()
+			defer wg.Done()
+			ch <- serve(handler, body)
+		}()
+		return ch
+	}
+
+	It("should parse the overload behaviors", func() {
+		Expect(ParseOverloadBehavior("allow")).To(Equal(OverloadAllow))
+		Expect(ParseOverloadBehavior("reject")).To(Equal(OverloadReject))
+		_, err := ParseOverloadBehavior("drop")
+		Expect(err).To(MatchError(ContainSubstring(`invalid overload behavior "drop"`)))
+	})
+
+	It("should queue requests and reject them when the queue is full", func() {
+		handler := Chain(blocked, LimitInFlight(InFlightLimits{MaxInFlight: 1, MaxQueued: 1, QueueTimeout: time.Minute, Behavior: OverloadReject}))
+		first := serveAsync(handler)
+		Eventually(started).Should(Receive())
+		queued := serveAsync(handler)
+		Eventually(queueDepth).Should(Equal(1.0))
+
+		w := serve(handler, body)
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(w.Header().Get("Retry-After")).To(Equal("1"))
+		Expect(shedRequests("queue-full", OverloadReject)).To(Equal(1.0))
+
+		release <- struct{}{}
+		Eventually(first).Should(Receive(HaveField("Code", http.StatusOK)))
+		Eventually(started).Should(Receive())
+		Expect(queueDepth()).To(Equal(0.0))
+		release <- struct{}{}
+		Eventually(queued).Should(Receive(HaveField("Code", http.StatusOK)))
+	})
+
+	It("should shed queued requests after the queue timeout", func() {
+		handler := Chain(blocked, LimitInFlight(InFlightLimits{MaxInFlight: 1, MaxQueued: 1, QueueTimeout: 10 * time.Millisecond, Behavior: OverloadReject}))
+		serveAsync(handler)
+		Eventually(started).Should(Receive())
+
+		w := serve(handler, body)
+		Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+		Expect(shedRequests("queue-timeout", OverloadReject)).To(Equal(1.0))
+		Expect(queueDepth()).To(Equal(0.0))
+	})
+
+	It("should allow shed requests with a warning", func() {
+		handler := Chain(blocked, LimitInFlight(InFlightLimits{MaxInFlight: 1, QueueTimeout: time.Minute, Behavior: OverloadAllow}))
+		serveAsync(handler)
+		Eventually(started).Should(Receive())
+
+		w := serve(handler, body)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		ar := admissionv1.AdmissionReview{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &ar)).To(Succeed())
+		Expect(string(ar.Response.UID)).To(Equal("5678"))
+		Expect(ar.Response.Allowed).To(BeTrue())
+		Expect(ar.Response.Warnings).To(ConsistOf(ContainSubstring("overloaded")))
+		Expect(shedRequests("queue-full", OverloadAllow)).To(Equal(1.0))
+	})
+})
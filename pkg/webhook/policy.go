@@ -0,0 +1,274 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/golang/glog"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PolicyResource is the resource of the cluster scoped NetworkAttachmentPolicy CRD
+var PolicyResource = schema.GroupVersionResource{
+	Group:    "admission.k8s.cni.cncf.io",
+	Version:  "v1alpha1",
+	Resource: "networkattachmentpolicies",
+}
+
+var (
+	// policyIndexer caches NetworkAttachmentPolicy objects, nil if policies are disabled
+	policyIndexer cache.Indexer
+	// policyInformer fills the cache, it is kept for the health checks
+	policyInformer cache.SharedIndexInformer
+)
+
+// NetworkAttachmentPolicy restricts the network attachment definitions
+// that may be created in the namespaces it selects
+type NetworkAttachmentPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NetworkAttachmentPolicySpec `json:"spec"`
+}
+
+// NetworkAttachmentPolicySpec is the specification of a NetworkAttachmentPolicy.
+// A policy without namespaces and namespaceSelector applies to every namespace.
+type NetworkAttachmentPolicySpec struct {
+	// Namespaces lists the names of the namespaces the policy applies to
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector selects the namespaces the policy applies to by label
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// AllowedPluginTypes lists the only CNI plugin types that may be used, if set
+	AllowedPluginTypes []string `json:"allowedPluginTypes,omitempty"`
+	// DeniedPluginTypes lists CNI plugin types that must not be used
+	DeniedPluginTypes []string `json:"deniedPluginTypes,omitempty"`
+	// AllowedHostInterfaces lists the host interface names or glob patterns
+	// that may be referenced by the plugins, if set
+	AllowedHostInterfaces []string `json:"allowedHostInterfaces,omitempty"`
+	// AllowPromisc permits putting interfaces in promiscuous mode, if every
+	// policy of the namespace sets it
+	AllowPromisc bool `json:"allowPromisc,omitempty"`
+	// AllowAllmulti permits enabling all-multicast mode on interfaces, if
+	// every policy of the namespace sets it
+	AllowAllmulti bool `json:"allowAllmulti,omitempty"`
+	// MaxMTU replaces the largest MTU plugins may set, the lowest of the
+	// policies of the namespace applies
+	MaxMTU int `json:"maxMTU,omitempty"`
+}
+
+// hostInterfaceFields are the plugin fields that refer to host interfaces
+var hostInterfaceFields = []string{"master", "device", "bridge", "pciBusID"}
+
+// StartPolicyInformer starts the informer for NetworkAttachmentPolicy objects
+// and waits for its cache to be synced
+func StartPolicyInformer(stopCh <-chan struct{}) error {
+	policyInformer = cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return dynamicClient.Resource(PolicyResource).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return dynamicClient.Resource(PolicyResource).Watch(context.TODO(), options)
+			},
+		},
+		&unstructured.Unstructured{},
+		0,
+		cache.Indexers{},
+	)
+	// keep typed policies in the cache, so that they are converted only once
+	if err := policyInformer.SetTransform(toNetworkAttachmentPolicy); err != nil {
+		return err
+	}
+
+	go policyInformer.Run(stopCh)
+
+	if !cache.WaitForCacheSync(stopCh, policyInformer.HasSynced) {
+		return fmt.Errorf("timed out waiting for policy cache to sync")
+	}
+
+	policyIndexer = policyInformer.GetIndexer()
+	glog.Infof("network attachment policies synced")
+	return nil
+}
+
+func toNetworkAttachmentPolicy(obj interface{}) (interface{}, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return obj, nil
+	}
+	policy := &NetworkAttachmentPolicy{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, policy); err != nil {
+		return nil, fmt.Errorf("failed to convert %s: %v", u.GetName(), err)
+	}
+	return policy, nil
+}
+
+// namespaceLabels returns the labels of the namespace, if known
+func namespaceLabels(namespace string) labels.Set {
+	if ns := getNamespace(namespace); ns != nil {
+		return labels.Set(ns.GetLabels())
+	}
+	return nil
+}
+
+// appliesTo checks whether the policy selects the namespace
+func (p *NetworkAttachmentPolicy) appliesTo(namespace string, nsLabels labels.Set) bool {
+	if len(p.Spec.Namespaces) == 0 && p.Spec.NamespaceSelector == nil {
+		return true
+	}
+	for _, ns := range p.Spec.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	if p.Spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(p.Spec.NamespaceSelector)
+		if err != nil {
+			glog.Errorf("ignoring invalid namespaceSelector of policy %s: %v", p.GetName(), err)
+			return false
+		}
+		return selector.Matches(nsLabels)
+	}
+	return false
+}
+
+// policiesForNamespace returns the policies that apply to the namespace, sorted by name
+func policiesForNamespace(namespace string) []*NetworkAttachmentPolicy {
+	if policyIndexer == nil {
+		return nil
+	}
+	nsLabels := namespaceLabels(namespace)
+
+	var policies []*NetworkAttachmentPolicy
+	for _, obj := range policyIndexer.List() {
+		policy, ok := obj.(*NetworkAttachmentPolicy)
+		if ok && policy.appliesTo(namespace, nsLabels) {
+			policies = append(policies, policy)
+		}
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].GetName() < policies[j].GetName()
+	})
+	return policies
+}
+
+// isPluginTypeAllowed checks the plugin type against every policy,
+// a type must be allowed by all of them
+func isPluginTypeAllowed(pluginType string, policies []*NetworkAttachmentPolicy) bool {
+	for _, policy := range policies {
+		if containsString(policy.Spec.DeniedPluginTypes, pluginType) {
+			return false
+		}
+		if len(policy.Spec.AllowedPluginTypes) > 0 && !containsString(policy.Spec.AllowedPluginTypes, pluginType) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHostInterfaceAllowed checks the host interface against every policy
+// that restricts host interfaces, it must match a pattern of each of them
+func isHostInterfaceAllowed(name string, policies []*NetworkAttachmentPolicy) bool {
+	for _, policy := range policies {
+		if len(policy.Spec.AllowedHostInterfaces) == 0 {
+			continue
+		}
+		if !matchesAny(policy.Spec.AllowedHostInterfaces, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// forbiddenPluginTypes returns an error for each plugin whose type is not allowed by the policies
+func forbiddenPluginTypes(plugins []cniPlugin, policies []*NetworkAttachmentPolicy, namespace string) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, plugin := range plugins {
+		pluginType, _ := plugin.conf["type"].(string)
+		if !isPluginTypeAllowed(pluginType, policies) {
+			allErrs = append(allErrs, field.Forbidden(plugin.path.Child("type"), fmt.Sprintf("CNI plugin type %q is not allowed in namespace %s", pluginType, namespace)))
+		}
+	}
+	return allErrs
+}
+
+// forbiddenHostInterfaces returns an error for each host interface reference not allowed by the policies
+func forbiddenHostInterfaces(plugins []cniPlugin, policies []*NetworkAttachmentPolicy, namespace string) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, plugin := range plugins {
+		for _, name := range hostInterfaceFields {
+			value, ok := plugin.conf[name].(string)
+			if !ok || value == "" {
+				continue
+			}
+			if !isHostInterfaceAllowed(value, policies) {
+				allErrs = append(allErrs, field.Forbidden(plugin.path.Child(name), fmt.Sprintf("host interface %q is not allowed in namespace %s", value, namespace)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateNetworkAttachmentPolicies checks the net-attach-def against the policies of its namespace.
+// Configs that cannot be parsed are left to validateNetworkAttachmentDefinition.
+func validateNetworkAttachmentPolicies(netAttachDef netv1.NetworkAttachmentDefinition, namespace string) (bool, error) {
+	policies := policiesForNamespace(namespace)
+	if len(policies) == 0 || netAttachDef.Spec.Config == "" {
+		return true, nil
+	}
+
+	plugins, err := getCNIPlugins([]byte(netAttachDef.Spec.Config))
+	if err != nil {
+		return true, nil
+	}
+
+	allErrs := forbiddenPluginTypes(plugins, policies, namespace)
+	allErrs = append(allErrs, forbiddenHostInterfaces(plugins, policies, namespace)...)
+	if len(allErrs) > 0 {
+		return false, allErrs.ToAggregate()
+	}
+	return true, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny checks whether name matches one of the glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
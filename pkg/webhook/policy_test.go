@@ -0,0 +1,232 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newNamespace(name string, labels map[string]string) *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+	}
+}
+
+func newNetworkAttachmentPolicy(name string, spec NetworkAttachmentPolicySpec) *NetworkAttachmentPolicy {
+	return &NetworkAttachmentPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: spec,
+	}
+}
+
+func newNetAttachDef(config string) netv1.NetworkAttachmentDefinition {
+	return netv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-valid-name",
+		},
+		Spec: netv1.NetworkAttachmentDefinitionSpec{
+			Config: config,
+		},
+	}
+}
+
+// setPolicies replaces the policy and namespace caches with the given objects
+func setPolicies(namespaces []*v1.Namespace, policies ...*NetworkAttachmentPolicy) {
+	namespaceIndexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range namespaces {
+		Expect(namespaceIndexer.Add(ns)).To(Succeed())
+	}
+	policyIndexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, policy := range policies {
+		Expect(policyIndexer.Add(policy)).To(Succeed())
+	}
+}
+
+func resetPolicies() {
+	namespaceIndexer = nil
+	policyIndexer = nil
+}
+
+var _ = Describe("Network attachment policies", func() {
+	AfterEach(resetPolicies)
+
+	Describe("Converting unstructured policies", func() {
+		It("should decode the spec", func() {
+			obj, err := toNetworkAttachmentPolicy(&unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "admission.k8s.cni.cncf.io/v1alpha1",
+				"kind":       "NetworkAttachmentPolicy",
+				"metadata":   map[string]interface{}{"name": "tenants"},
+				"spec": map[string]interface{}{
+					"namespaces":         []interface{}{"tenant"},
+					"allowedPluginTypes": []interface{}{"bridge"},
+				},
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			policy := obj.(*NetworkAttachmentPolicy)
+			Expect(policy.GetName()).To(Equal("tenants"))
+			Expect(policy.Spec.Namespaces).To(ConsistOf("tenant"))
+			Expect(policy.Spec.AllowedPluginTypes).To(ConsistOf("bridge"))
+		})
+	})
+
+	Describe("Selecting policies", func() {
+		BeforeEach(func() {
+			setPolicies(
+				[]*v1.Namespace{
+					newNamespace("infra", map[string]string{"tier": "infra"}),
+					newNamespace("tenant", map[string]string{"tier": "tenant"}),
+				},
+				newNetworkAttachmentPolicy("everyone", NetworkAttachmentPolicySpec{}),
+				newNetworkAttachmentPolicy("by-name", NetworkAttachmentPolicySpec{Namespaces: []string{"tenant"}}),
+				newNetworkAttachmentPolicy("by-label", NetworkAttachmentPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "infra"}},
+				}),
+			)
+		})
+
+		It("should return policies matching the namespace name", func() {
+			names := []string{}
+			for _, p := range policiesForNamespace("tenant") {
+				names = append(names, p.GetName())
+			}
+			Expect(names).To(Equal([]string{"by-name", "everyone"}))
+		})
+
+		It("should return policies matching the namespace labels", func() {
+			names := []string{}
+			for _, p := range policiesForNamespace("infra") {
+				names = append(names, p.GetName())
+			}
+			Expect(names).To(Equal([]string{"by-label", "everyone"}))
+		})
+
+		It("should only return unrestricted policies for unknown namespaces", func() {
+			policies := policiesForNamespace("unknown")
+			Expect(policies).To(HaveLen(1))
+			Expect(policies[0].GetName()).To(Equal("everyone"))
+		})
+	})
+
+	Describe("Allowed plugin types", func() {
+		BeforeEach(func() {
+			setPolicies(
+				[]*v1.Namespace{
+					newNamespace("infra", map[string]string{"tier": "infra"}),
+					newNamespace("tenant", nil),
+				},
+				newNetworkAttachmentPolicy("tenants", NetworkAttachmentPolicySpec{
+					Namespaces:         []string{"tenant"},
+					AllowedPluginTypes: []string{"bridge", "ovn-k8s-cni-overlay", "tuning"},
+				}),
+				newNetworkAttachmentPolicy("host-networks", NetworkAttachmentPolicySpec{
+					NamespaceSelector: &metav1.LabelSelector{
+						MatchExpressions: []metav1.LabelSelectorRequirement{{
+							Key:      "tier",
+							Operator: metav1.LabelSelectorOpNotIn,
+							Values:   []string{"infra"},
+						}},
+					},
+					DeniedPluginTypes: []string{"host-device", "sriov", "macvlan"},
+				}),
+			)
+		})
+
+		DescribeTable("validating net-attach-defs",
+			func(namespace, config string, out bool, forbidden string) {
+				allowed, err := validateNetworkAttachmentPolicies(newNetAttachDef(config), namespace)
+				Expect(allowed).To(Equal(out))
+				if out {
+					Expect(err).NotTo(HaveOccurred())
+				} else {
+					Expect(err).To(MatchError(ContainSubstring(forbidden)))
+				}
+			},
+			Entry("allowed single config", "tenant", `{"cniVersion": "0.3.1", "type": "bridge"}`, true, ""),
+			Entry("allowed config list", "tenant", `{"cniVersion": "0.3.1", "plugins": [{"type": "bridge"}, {"type": "tuning"}]}`, true, ""),
+			Entry("empty config", "tenant", ``, true, ""),
+			Entry("type outside of the allowed list", "tenant", `{"cniVersion": "0.3.1", "type": "ipvlan"}`, false, `spec.config.type: Forbidden: CNI plugin type "ipvlan"`),
+			Entry("denied type in a config list", "tenant", `{"cniVersion": "0.3.1", "plugins": [{"type": "sriov"}, {"type": "tuning"}, {"type": "macvlan"}]}`, false, `[spec.config.plugins[0].type: Forbidden: CNI plugin type "sriov" is not allowed in namespace tenant, spec.config.plugins[2].type: Forbidden: CNI plugin type "macvlan"`),
+			Entry("denied type in another namespace", "other", `{"cniVersion": "0.3.1", "type": "host-device"}`, false, `CNI plugin type "host-device" is not allowed in namespace other`),
+			Entry("host type in the infra namespace", "infra", `{"cniVersion": "0.3.1", "type": "host-device"}`, true, ""),
+		)
+
+		It("should name the namespace in the error", func() {
+			_, err := validateNetworkAttachmentPolicies(newNetAttachDef(`{"type": "sriov"}`), "tenant")
+			Expect(err).To(MatchError(`spec.config.type: Forbidden: CNI plugin type "sriov" is not allowed in namespace tenant`))
+		})
+	})
+
+	Context("when policies are disabled", func() {
+		It("should allow any plugin type", func() {
+			allowed, err := validateNetworkAttachmentPolicies(newNetAttachDef(`{"type": "host-device"}`), "tenant")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		})
+	})
+
+	Describe("Allowed host interfaces", func() {
+		BeforeEach(func() {
+			setPolicies(
+				[]*v1.Namespace{newNamespace("tenant", nil)},
+				newNetworkAttachmentPolicy("tenants", NetworkAttachmentPolicySpec{
+					Namespaces:            []string{"tenant"},
+					AllowedHostInterfaces: []string{"eth1", "vlan1[0-9][0-9]", "br-tenant-*", "0000:03:00.*"},
+				}),
+				newNetworkAttachmentPolicy("no-restriction", NetworkAttachmentPolicySpec{}),
+			)
+		})
+
+		DescribeTable("validating net-attach-defs",
+			func(namespace, config string, out bool, forbidden string) {
+				allowed, err := validateNetworkAttachmentPolicies(newNetAttachDef(config), namespace)
+				Expect(allowed).To(Equal(out))
+				if out {
+					Expect(err).NotTo(HaveOccurred())
+				} else {
+					Expect(err).To(MatchError(ContainSubstring(forbidden)))
+				}
+			},
+			Entry("exact master", "tenant", `{"type": "macvlan", "master": "eth1"}`, true, ""),
+			Entry("glob master", "tenant", `{"type": "ipvlan", "master": "vlan123"}`, true, ""),
+			Entry("glob bridge", "tenant", `{"type": "bridge", "bridge": "br-tenant-a"}`, true, ""),
+			Entry("glob pciBusID", "tenant", `{"type": "host-device", "pciBusID": "0000:03:00.1"}`, true, ""),
+			Entry("no host interface", "tenant", `{"type": "macvlan"}`, true, ""),
+			Entry("forbidden master", "tenant", `{"type": "macvlan", "master": "bond0"}`, false, `spec.config.master: Forbidden: host interface "bond0"`),
+			Entry("forbidden device", "tenant", `{"type": "host-device", "device": "eth0"}`, false, `spec.config.device: Forbidden: host interface "eth0"`),
+			Entry("forbidden entries in a config list", "tenant",
+				`{"plugins": [{"type": "bridge", "bridge": "br-storage"}, {"type": "host-device", "pciBusID": "0000:04:00.0"}]}`,
+				false, `[spec.config.plugins[0].bridge: Forbidden: host interface "br-storage" is not allowed in namespace tenant, spec.config.plugins[1].pciBusID: Forbidden: host interface "0000:04:00.0"`),
+			Entry("any interface in another namespace", "other", `{"type": "macvlan", "master": "bond0"}`, true, ""),
+		)
+
+		It("should name the namespace in the error", func() {
+			_, err := validateNetworkAttachmentPolicies(newNetAttachDef(`{"type": "macvlan", "master": "bond0"}`), "tenant")
+			Expect(err).To(MatchError(`spec.config.master: Forbidden: host interface "bond0" is not allowed in namespace tenant`))
+		})
+	})
+})
@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/golang/glog"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// namespace annotations overriding the global quotas
+	maxNetworksPerPodAnnotationKey = "k8s.v1.cni.cncf.io/max-networks-per-pod"
+	maxNetAttachDefsAnnotationKey  = "k8s.v1.cni.cncf.io/max-net-attach-defs"
+)
+
+// Quotas limits the networks requested by pods and the net-attach-defs in a namespace,
+// zero means unlimited
+type Quotas struct {
+	// MaxNetworksPerPod is the largest number of network selection elements per pod
+	MaxNetworksPerPod int
+	// MaxNetAttachDefsPerNamespace is the largest number of net-attach-defs per namespace
+	MaxNetAttachDefsPerNamespace int
+}
+
+var quotas Quotas
+
+// SetQuotas configures the global quotas, namespaces may override them with annotations
+func SetQuotas(q Quotas) {
+	quotas = q
+}
+
+// namespaceQuota returns the quota set by the namespace annotation, or the global one
+func namespaceQuota(namespace, annotationKey string, global int) int {
+	ns := getNamespace(namespace)
+	if ns == nil {
+		return global
+	}
+	value, ok := ns.GetAnnotations()[annotationKey]
+	if !ok {
+		return global
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 0 {
+		glog.Errorf("ignoring invalid %s annotation %q of namespace %s", annotationKey, value, namespace)
+		return global
+	}
+	return limit
+}
+
+// validatePodNetworkQuota checks the number of networks requested by the pod
+func validatePodNetworkQuota(pod v1.Pod, namespace string) (bool, error) {
+	limit := namespaceQuota(namespace, maxNetworksPerPodAnnotationKey, quotas.MaxNetworksPerPod)
+	annotation := pod.GetAnnotations()[networksAnnotationKey]
+	if limit == 0 || annotation == "" {
+		return true, nil
+	}
+
+	// malformed annotations are denied by validatePodIsolation
+	networks, err := parsePodNetworkAnnotation(annotation, namespace)
+	if err != nil {
+		return true, nil
+	}
+	if len(networks) > limit {
+		err := field.TooMany(annotationsPath.Key(networksAnnotationKey), len(networks), limit)
+		err.Detail = fmt.Sprintf("pod requests %d networks, the limit in namespace %s is %d", len(networks), namespace, limit)
+		return false, field.ErrorList{err}.ToAggregate()
+	}
+	return true, nil
+}
+
+// validateNetAttachDefQuota checks whether another net-attach-def may be created in the namespace
+func validateNetAttachDefQuota(namespace string) (bool, error) {
+	limit := namespaceQuota(namespace, maxNetAttachDefsAnnotationKey, quotas.MaxNetAttachDefsPerNamespace)
+	if limit == 0 || netAttachDefIndexer == nil {
+		return true, nil
+	}
+
+	existing, err := netAttachDefIndexer.ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		return false, field.ErrorList{field.InternalError(namespacePath, err)}.ToAggregate()
+	}
+	if len(existing) >= limit {
+		return false, field.ErrorList{field.Forbidden(namespacePath, fmt.Sprintf("namespace %s has %d net-attach-defs, the limit is %d", namespace, len(existing), limit))}.ToAggregate()
+	}
+	return true, nil
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newPod(annotations map[string]string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "some-pod",
+			Annotations: annotations,
+		},
+	}
+}
+
+// setNetAttachDefs replaces the net-attach-def cache with the given objects
+func setNetAttachDefs(netAttachDefs ...*netv1.NetworkAttachmentDefinition) {
+	netAttachDefIndexer = cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, netAttachDef := range netAttachDefs {
+		Expect(netAttachDefIndexer.Add(netAttachDef)).To(Succeed())
+	}
+}
+
+var _ = Describe("Quotas", func() {
+	BeforeEach(func() {
+		SetQuotas(Quotas{MaxNetworksPerPod: 2, MaxNetAttachDefsPerNamespace: 2})
+		tenant := newNamespace("tenant", nil)
+		infra := newNamespace("infra", nil)
+		infra.Annotations = map[string]string{
+			maxNetworksPerPodAnnotationKey: "4",
+			maxNetAttachDefsAnnotationKey:  "3",
+		}
+		broken := newNamespace("broken", nil)
+		broken.Annotations = map[string]string{
+			maxNetworksPerPodAnnotationKey: "many",
+		}
+		unlimited := newNamespace("unlimited", nil)
+		unlimited.Annotations = map[string]string{
+			maxNetworksPerPodAnnotationKey: "0",
+			maxNetAttachDefsAnnotationKey:  "0",
+		}
+		setPolicies([]*v1.Namespace{tenant, infra, broken, unlimited})
+
+		var netAttachDefs []*netv1.NetworkAttachmentDefinition
+		for _, ns := range []string{"tenant", "infra", "unlimited"} {
+			for _, name := range []string{"net1", "net2"} {
+				netAttachDefs = append(netAttachDefs, &netv1.NetworkAttachmentDefinition{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				})
+			}
+		}
+		setNetAttachDefs(netAttachDefs...)
+	})
+
+	AfterEach(func() {
+		SetQuotas(Quotas{})
+		resetPolicies()
+		netAttachDefIndexer = nil
+	})
+
+	DescribeTable("networks per pod",
+		func(namespace, networks string, out bool) {
+			allowed, err := validatePodNetworkQuota(newPod(map[string]string{networksAnnotationKey: networks}), namespace)
+			Expect(allowed).To(Equal(out))
+			if !out {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("within the global limit", "tenant", "net1,net2", true),
+		Entry("beyond the global limit", "tenant", "net1,net2,net3", false),
+		Entry("beyond the global limit in JSON format", "tenant", `[{"name": "net1"}, {"name": "net1"}, {"name": "net1"}]`, false),
+		Entry("within the namespace limit", "infra", "net1,net2,net3,net4", true),
+		Entry("beyond the namespace limit", "infra", "net1,net2,net3,net4,net5", false),
+		Entry("with an invalid namespace limit", "broken", "net1,net2,net3", false),
+		Entry("with an unlimited namespace", "unlimited", "net1,net2,net3,net4,net5", true),
+	)
+
+	It("should state the count and the limit when denying a pod", func() {
+		_, err := validatePodNetworkQuota(newPod(map[string]string{networksAnnotationKey: "a,b,c"}), "tenant")
+		Expect(err).To(MatchError("metadata.annotations[k8s.v1.cni.cncf.io/networks]: Too many: 3: pod requests 3 networks, the limit in namespace tenant is 2"))
+	})
+
+	It("should allow pods without networks", func() {
+		allowed, err := validatePodNetworkQuota(newPod(nil), "tenant")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeTrue())
+	})
+
+	DescribeTable("net-attach-defs per namespace",
+		func(namespace string, out bool) {
+			allowed, err := validateNetAttachDefQuota(namespace)
+			Expect(allowed).To(Equal(out))
+			if !out {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("at the global limit", "tenant", false),
+		Entry("below the namespace limit", "infra", true),
+		Entry("with an unlimited namespace", "unlimited", true),
+		Entry("in an empty namespace", "broken", true),
+	)
+
+	It("should state the count and the limit when denying a net-attach-def", func() {
+		_, err := validateNetAttachDefQuota("tenant")
+		Expect(err).To(MatchError("metadata.namespace: Forbidden: namespace tenant has 2 net-attach-defs, the limit is 2"))
+	})
+})
@@ -0,0 +1,313 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookShutdownAction defines what happens to the registered webhook
+// configurations when the webhook stops
+type WebhookShutdownAction string
+
+const (
+	// WebhookShutdownKeep leaves the webhook configurations as they are
+	WebhookShutdownKeep WebhookShutdownAction = "keep"
+	// WebhookShutdownDisable sets the failure policy of the webhooks to Ignore,
+	// so that requests are admitted while no webhook serves them
+	WebhookShutdownDisable WebhookShutdownAction = "disable"
+	// WebhookShutdownDelete deletes the webhook configurations
+	WebhookShutdownDelete WebhookShutdownAction = "delete"
+
+	// DefaultWebhookTimeoutSeconds is the default timeoutSeconds of the API
+	DefaultWebhookTimeoutSeconds = 10
+
+	// webhookManagedByLabel marks the webhook configurations registered by the webhook
+	webhookManagedByLabel = "app.kubernetes.io/managed-by"
+	webhookManagedBy      = "net-attach-def-admission-controller"
+	// webhookOwnerAnnotation names the Deployment (namespace/name) owning a
+	// registered webhook configuration. Cluster scoped objects cannot have a
+	// namespaced owner reference, so ownership is recorded here instead.
+	webhookOwnerAnnotation = "k8s.v1.cni.cncf.io/webhook-owner"
+)
+
+// webhookDefinition describes one of the webhooks served, as in deployments/
+type webhookDefinition struct {
+	configurationName string
+	path              string
+	rules             []admissionregistrationv1.RuleWithOperations
+	// ignoresNamespaces tells whether the webhook skips the namespaces ignored
+	// by the pod controller
+	ignoresNamespaces bool
+}
+
+// webhookDefinitions are the webhooks that can be registered, by name
+var webhookDefinitions = map[string]webhookDefinition{
+	"validate": {
+		configurationName: "net-attach-def-admission-controller-validating-config",
+		path:              "/validate",
+		rules: []admissionregistrationv1.RuleWithOperations{{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"k8s.cni.cncf.io"},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"network-attachment-definitions"},
+			},
+		}},
+	},
+	"isolate": {
+		configurationName: "net-attach-def-admission-controller-isolating-config",
+		path:              "/isolate",
+		rules: []admissionregistrationv1.RuleWithOperations{{
+			Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+			Rule: admissionregistrationv1.Rule{
+				APIGroups:   []string{"apps", ""},
+				APIVersions: []string{"v1"},
+				Resources:   []string{"pods"},
+			},
+		}},
+		ignoresNamespaces: true,
+	},
+}
+
+// WebhookRegistration configures the webhook configurations the webhook
+// registers at startup
+type WebhookRegistration struct {
+	// Webhooks are the names of the webhooks to register, validate or isolate
+	Webhooks []string
+	// ServiceNamespace and ServiceName name the service of the webhook
+	ServiceNamespace string
+	ServiceName      string
+	// Owner is the name of the Deployment of the webhook, in ServiceNamespace
+	Owner            string
+	FailurePolicy    admissionregistrationv1.FailurePolicyType
+	TimeoutSeconds   int32
+	IgnoreNamespaces []string
+	// CABundle is set in the webhook configurations, the current CA bundle is
+	// kept if it is empty
+	CABundle       []byte
+	ShutdownAction WebhookShutdownAction
+}
+
+// ParseWebhookShutdownAction checks the name of a webhook shutdown action
+func ParseWebhookShutdownAction(value string) (WebhookShutdownAction, error) {
+	switch action := WebhookShutdownAction(value); action {
+	case WebhookShutdownKeep, WebhookShutdownDisable, WebhookShutdownDelete:
+		return action, nil
+	}
+	return "", fmt.Errorf("invalid webhook shutdown action %q, must be one of keep, disable, delete", value)
+}
+
+// ParseFailurePolicy checks the name of a failure policy
+func ParseFailurePolicy(value string) (admissionregistrationv1.FailurePolicyType, error) {
+	switch policy := admissionregistrationv1.FailurePolicyType(value); policy {
+	case admissionregistrationv1.Fail, admissionregistrationv1.Ignore:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid failure policy %q, must be one of Fail, Ignore", value)
+}
+
+// owner returns the value of the owner annotation
+func (r *WebhookRegistration) owner() string {
+	return fmt.Sprintf("%s/%s", r.ServiceNamespace, r.Owner)
+}
+
+// configuration returns the webhook configuration for a webhook definition.
+// The fields defaulted by the API server are set, so that an unchanged
+// configuration compares equal.
+func (r *WebhookRegistration) configuration(definition webhookDefinition) *admissionregistrationv1.ValidatingWebhookConfiguration {
+	namespaceSelector := &metav1.LabelSelector{}
+	if definition.ignoresNamespaces && len(r.IgnoreNamespaces) > 0 {
+		namespaceSelector.MatchExpressions = []metav1.LabelSelectorRequirement{{
+			Key:      v1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   r.IgnoreNamespaces,
+		}}
+	}
+	rules := make([]admissionregistrationv1.RuleWithOperations, len(definition.rules))
+	for i, rule := range definition.rules {
+		rules[i] = *rule.DeepCopy()
+		scope := admissionregistrationv1.AllScopes
+		rules[i].Scope = &scope
+	}
+	path := definition.path
+	port := int32(443)
+	failurePolicy := r.FailurePolicy
+	matchPolicy := admissionregistrationv1.Equivalent
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	timeoutSeconds := r.TimeoutSeconds
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        definition.configurationName,
+			Labels:      map[string]string{webhookManagedByLabel: webhookManagedBy},
+			Annotations: map[string]string{webhookOwnerAnnotation: r.owner()},
+		},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+			Name: definition.configurationName + ".k8s.io",
+			ClientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{
+					Namespace: r.ServiceNamespace,
+					Name:      r.ServiceName,
+					Path:      &path,
+					Port:      &port,
+				},
+				CABundle: r.CABundle,
+			},
+			Rules:                   rules,
+			FailurePolicy:           &failurePolicy,
+			MatchPolicy:             &matchPolicy,
+			NamespaceSelector:       namespaceSelector,
+			ObjectSelector:          &metav1.LabelSelector{},
+			SideEffects:             &sideEffects,
+			TimeoutSeconds:          &timeoutSeconds,
+			AdmissionReviewVersions: []string{"v1", "v1beta1"},
+		}},
+	}
+}
+
+// checkOwner returns an error if the webhook configuration is registered by another Deployment
+func (r *WebhookRegistration) checkOwner(configuration *admissionregistrationv1.ValidatingWebhookConfiguration) error {
+	if owner, ok := configuration.Annotations[webhookOwnerAnnotation]; ok && owner != r.owner() {
+		return fmt.Errorf("validating webhook configuration %s is owned by %s", configuration.Name, owner)
+	}
+	return nil
+}
+
+// RegisterWebhooks creates the webhook configurations, or updates them if they
+// differ from what the webhook serves
+func RegisterWebhooks(ctx context.Context, r *WebhookRegistration) error {
+	client := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	for _, name := range r.Webhooks {
+		definition, ok := webhookDefinitions[name]
+		if !ok {
+			return fmt.Errorf("unknown webhook %q, must be one of validate, isolate", name)
+		}
+		desired := r.configuration(definition)
+
+		current, err := client.Get(ctx, desired.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err := client.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("error creating validating webhook configuration %s: %v", desired.Name, err)
+			}
+			glog.Infof("created validating webhook configuration %s", desired.Name)
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error getting validating webhook configuration %s: %v", desired.Name, err)
+		}
+		if err := r.checkOwner(current); err != nil {
+			return err
+		}
+
+		updated := current.DeepCopy()
+		if updated.Labels == nil {
+			updated.Labels = map[string]string{}
+		}
+		updated.Labels[webhookManagedByLabel] = webhookManagedBy
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[webhookOwnerAnnotation] = r.owner()
+		updated.Webhooks = desired.Webhooks
+		if len(r.CABundle) == 0 && len(current.Webhooks) > 0 {
+			updated.Webhooks[0].ClientConfig.CABundle = current.Webhooks[0].ClientConfig.CABundle
+		}
+		if equality.Semantic.DeepEqual(current, updated) {
+			continue
+		}
+		if _, err := client.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating validating webhook configuration %s: %v", desired.Name, err)
+		}
+		glog.Infof("updated validating webhook configuration %s", desired.Name)
+	}
+	return nil
+}
+
+// ownerRemoved tells whether the Deployment owning the webhook configurations
+// is deleted or scaled to zero, rather than replacing its pods
+func (r *WebhookRegistration) ownerRemoved(ctx context.Context) (bool, error) {
+	deployment, err := clientset.AppsV1().Deployments(r.ServiceNamespace).Get(ctx, r.Owner, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("error getting deployment %s: %v", r.owner(), err)
+	}
+	if deployment.DeletionTimestamp != nil {
+		return true, nil
+	}
+	return deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0, nil
+}
+
+// DeregisterWebhooks applies the shutdown action to the webhook configurations
+// owned by the Deployment, once the Deployment is deleted or scaled to zero.
+// The configurations are left to the other pods of the Deployment otherwise,
+// such as the pods replacing this one during a rolling update.
+func DeregisterWebhooks(ctx context.Context, r *WebhookRegistration) error {
+	if r.ShutdownAction == WebhookShutdownKeep || r.ShutdownAction == "" {
+		return nil
+	}
+	removed, err := r.ownerRemoved(ctx)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		glog.Infof("leaving the webhook configurations to the other pods of deployment %s", r.owner())
+		return nil
+	}
+	client := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+	for _, name := range r.Webhooks {
+		definition, ok := webhookDefinitions[name]
+		if !ok {
+			continue
+		}
+		current, err := client.Get(ctx, definition.configurationName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("error getting validating webhook configuration %s: %v", definition.configurationName, err)
+		}
+		if current.Annotations[webhookOwnerAnnotation] != r.owner() {
+			glog.Infof("leaving validating webhook configuration %s, it is not owned by %s", current.Name, r.owner())
+			continue
+		}
+
+		if r.ShutdownAction == WebhookShutdownDelete {
+			if err := client.Delete(ctx, current.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error deleting validating webhook configuration %s: %v", current.Name, err)
+			}
+			glog.Infof("deleted validating webhook configuration %s", current.Name)
+			continue
+		}
+
+		updated := current.DeepCopy()
+		ignore := admissionregistrationv1.Ignore
+		for i := range updated.Webhooks {
+			updated.Webhooks[i].FailurePolicy = &ignore
+		}
+		if _, err := client.Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error disabling validating webhook configuration %s: %v", current.Name, err)
+		}
+		glog.Infof("disabled validating webhook configuration %s", current.Name)
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"context"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("Webhook registration", func() {
+	const (
+		validating = "net-attach-def-admission-controller-validating-config"
+		isolating  = "net-attach-def-admission-controller-isolating-config"
+	)
+
+	var (
+		ctx          context.Context
+		registration *WebhookRegistration
+		fakeClient   *fake.Clientset
+		oldClient    kubernetes.Interface
+	)
+
+	get := func(name string) *admissionregistrationv1.ValidatingWebhookConfiguration {
+		configuration, err := fakeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return configuration
+	}
+
+	create := func(configuration *admissionregistrationv1.ValidatingWebhookConfiguration) {
+		_, err := fakeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().Create(ctx, configuration, metav1.CreateOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	updates := func() int {
+		count := 0
+		for _, action := range fakeClient.Actions() {
+			if action.GetVerb() == "update" {
+				count++
+			}
+		}
+		return count
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		registration = &WebhookRegistration{
+			Webhooks:         []string{"validate", "isolate"},
+			ServiceNamespace: "kube-system",
+			ServiceName:      "webhook",
+			Owner:            "webhook-server",
+			FailurePolicy:    admissionregistrationv1.Fail,
+			TimeoutSeconds:   5,
+			IgnoreNamespaces: []string{"kube-system", "openshift-monitoring"},
+			ShutdownAction:   WebhookShutdownKeep,
+		}
+		fakeClient = fake.NewSimpleClientset()
+		oldClient = clientset
+		clientset = fakeClient
+	})
+
+	AfterEach(func() {
+		clientset = oldClient
+	})
+
+	It("should parse the shutdown actions and failure policies", func() {
+		Expect(ParseWebhookShutdownAction("disable")).To(Equal(WebhookShutdownDisable))
+		_, err := ParseWebhookShutdownAction("remove")
+		Expect(err).To(MatchError(ContainSubstring(`invalid webhook shutdown action "remove"`)))
+		Expect(ParseFailurePolicy("Ignore")).To(Equal(admissionregistrationv1.Ignore))
+		_, err = ParseFailurePolicy("fail")
+		Expect(err).To(MatchError(ContainSubstring(`invalid failure policy "fail"`)))
+	})
+
+	It("should create the webhook configurations", func() {
+		Expect(RegisterWebhooks(ctx, registration)).To(Succeed())
+
+		configuration := get(validating)
+		Expect(configuration.Annotations).To(HaveKeyWithValue("k8s.v1.cni.cncf.io/webhook-owner", "kube-system/webhook-server"))
+		Expect(configuration.Labels).To(HaveKeyWithValue("app.kubernetes.io/managed-by", "net-attach-def-admission-controller"))
+		Expect(configuration.Webhooks).To(HaveLen(1))
+		webhook := configuration.Webhooks[0]
+		Expect(*webhook.ClientConfig.Service.Path).To(Equal("/validate"))
+		Expect(webhook.ClientConfig.Service.Name).To(Equal("webhook"))
+		Expect(webhook.Rules[0].Resources).To(ConsistOf("network-attachment-definitions"))
+		Expect(*webhook.FailurePolicy).To(Equal(admissionregistrationv1.Fail))
+		Expect(*webhook.TimeoutSeconds).To(Equal(int32(5)))
+		Expect(webhook.NamespaceSelector.MatchExpressions).To(BeEmpty())
+
+		webhook = get(isolating).Webhooks[0]
+		Expect(*webhook.ClientConfig.Service.Path).To(Equal("/isolate"))
+		Expect(webhook.Rules[0].Resources).To(ConsistOf("pods"))
+		Expect(webhook.NamespaceSelector.MatchExpressions).To(ConsistOf(metav1.LabelSelectorRequirement{
+			Key:      v1.LabelMetadataName,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   []string{"kube-system", "openshift-monitoring"},
+		}))
+	})
+
+	It("should reconcile existing webhook configurations and keep their CA bundle", func() {
+		path := "/old"
+		create(&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: validating},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{{
+				Name: validating + ".k8s.io",
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service:  &admissionregistrationv1.ServiceReference{Namespace: "kube-system", Name: "webhook", Path: &path},
+					CABundle: []byte("ca"),
+				},
+			}},
+		})
+		registration.Webhooks = []string{"validate"}
+
+		Expect(RegisterWebhooks(ctx, registration)).To(Succeed())
+		webhook := get(validating).Webhooks[0]
+		Expect(*webhook.ClientConfig.Service.Path).To(Equal("/validate"))
+		Expect(webhook.ClientConfig.CABundle).To(Equal([]byte("ca")))
+		Expect(updates()).To(Equal(1))
+
+		Expect(RegisterWebhooks(ctx, registration)).To(Succeed())
+		Expect(updates()).To(Equal(1))
+
+		registration.CABundle = []byte("new ca")
+		Expect(RegisterWebhooks(ctx, registration)).To(Succeed())
+		Expect(get(validating).Webhooks[0].ClientConfig.CABundle).To(Equal([]byte("new ca")))
+	})
+
+	It("should not take over webhook configurations of another Deployment", func() {
+		create(&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        validating,
+				Annotations: map[string]string{"k8s.v1.cni.cncf.io/webhook-owner": "other/webhook-server"},
+			},
+		})
+		Expect(RegisterWebhooks(ctx, registration)).To(MatchError(ContainSubstring("is owned by other/webhook-server")))
+	})
+
+	It("should reject unknown webhooks", func() {
+		registration.Webhooks = []string{"mutate"}
+		Expect(RegisterWebhooks(ctx, registration)).To(MatchError(ContainSubstring(`unknown webhook "mutate"`)))
+	})
+
+	Context("on shutdown", func() {
+		BeforeEach(func() {
+			Expect(RegisterWebhooks(ctx, registration)).To(Succeed())
+		})
+
+		It("should keep the webhook configurations", func() {
+			Expect(DeregisterWebhooks(ctx, registration)).To(Succeed())
+			Expect(*get(validating).Webhooks[0].FailurePolicy).To(Equal(admissionregistrationv1.Fail))
+		})
+
+		It("should disable the webhook configurations", func() {
+			registration.ShutdownAction = WebhookShutdownDisable
+			Expect(DeregisterWebhooks(ctx, registration)).To(Succeed())
+			Expect(*get(validating).Webhooks[0].FailurePolicy).To(Equal(admissionregistrationv1.Ignore))
+			Expect(*get(isolating).Webhooks[0].FailurePolicy).To(Equal(admissionregistrationv1.Ignore))
+		})
+
+		It("should leave the webhook configurations to the pods replacing it", func() {
+			replicas := int32(1)
+			_, err := fakeClient.AppsV1().Deployments("kube-system").Create(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-server", Namespace: "kube-system"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}, metav1.CreateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			registration.ShutdownAction = WebhookShutdownDelete
+
+			// during a rolling update the new pod registers the webhooks,
+			// then the old pod stops
+			Expect(RegisterWebhooks(ctx, registration)).To(Succeed())
+			Expect(DeregisterWebhooks(ctx, registration)).To(Succeed())
+			Expect(*get(validating).Webhooks[0].FailurePolicy).To(Equal(admissionregistrationv1.Fail))
+			Expect(*get(isolating).Webhooks[0].FailurePolicy).To(Equal(admissionregistrationv1.Fail))
+
+			// the configurations are deregistered once scaled to zero
+			replicas = 0
+			_, err = fakeClient.AppsV1().Deployments("kube-system").Update(ctx, &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "webhook-server", Namespace: "kube-system"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			registration.ShutdownAction = WebhookShutdownDisable
+			Expect(DeregisterWebhooks(ctx, registration)).To(Succeed())
+			Expect(*get(validating).Webhooks[0].FailurePolicy).To(Equal(admissionregistrationv1.Ignore))
+		})
+
+		It("should delete the webhook configurations it owns", func() {
+			other := get(isolating)
+			other.Annotations["k8s.v1.cni.cncf.io/webhook-owner"] = "other/webhook-server"
+			_, err := fakeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().Update(ctx, other, metav1.UpdateOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			registration.ShutdownAction = WebhookShutdownDelete
+			Expect(DeregisterWebhooks(ctx, registration)).To(Succeed())
+			_, err = fakeClient.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, validating, metav1.GetOptions{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			get(isolating)
+		})
+	})
+})
@@ -0,0 +1,384 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	v1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RuleTargetNetworkAttachmentDefinition rules are evaluated by /validate
+	RuleTargetNetworkAttachmentDefinition = "NetworkAttachmentDefinition"
+	// RuleTargetPod rules are evaluated by /isolate
+	RuleTargetPod = "Pod"
+
+	// RuleSeverityDeny rules deny the request when they fail
+	RuleSeverityDeny = "Deny"
+	// RuleSeverityWarn rules return a warning to the client when they fail
+	RuleSeverityWarn = "Warn"
+
+	// RuleCostLimit is the maximum cost of evaluating a rule, rules whose worst
+	// case cost is estimated above it are rejected
+	RuleCostLimit = 10000000
+	// ruleMaxSize is the size of the lists, maps and strings the worst case cost
+	// of rules is estimated with, the size limit of the annotations of an object
+	ruleMaxSize = 256 << 10
+)
+
+// Rule is a CEL expression validating net-attach-defs or pods. The expression
+// has to evaluate to true for the object to pass.
+//
+// Expressions on net-attach-defs can use the variables object (the net-attach-def),
+// config (its decoded spec.config), plugins (the plugins of the config) and namespaceName.
+// Expressions on pods can use object (the pod), networks (the network selection
+// elements of its k8s.v1.cni.cncf.io/networks annotation) and namespaceName.
+type Rule struct {
+	Name       string `json:"name"`
+	Target     string `json:"target"`
+	Expression string `json:"expression"`
+	Message    string `json:"message"`
+	Severity   string `json:"severity,omitempty"`
+}
+
+// RuleFile is the format of the rules file
+type RuleFile struct {
+	Rules []Rule `json:"rules"`
+}
+
+type compiledRule struct {
+	Rule
+	program cel.Program
+}
+
+// ruleCostEstimator estimates the cost of rules on inputs of ruleMaxSize
+type ruleCostEstimator struct{}
+
+func (ruleCostEstimator) EstimateSize(element checker.AstNode) *checker.SizeEstimate {
+	return &checker.SizeEstimate{Min: 0, Max: ruleMaxSize}
+}
+
+func (ruleCostEstimator) EstimateCallCost(function, overloadID string, target *checker.AstNode, args []checker.AstNode) *checker.CallEstimate {
+	return nil
+}
+
+type ruleSet struct {
+	netAttachDef []compiledRule
+	pod          []compiledRule
+}
+
+var (
+	rulesMutex sync.RWMutex
+	rules      = &ruleSet{}
+
+	// loadedConfigFiles are the configuration files loaded once, by what they hold
+	loadedConfigFiles      = map[string]bool{}
+	loadedConfigFilesMutex sync.Mutex
+)
+
+// compileRules compiles every rule, failing on the first invalid one
+func compileRules(ruleList []Rule) (*ruleSet, error) {
+	netAttachDefEnv, err := cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("config", cel.DynType),
+		cel.Variable("plugins", cel.ListType(cel.DynType)),
+		cel.Variable("namespaceName", cel.StringType),
+	)
+	if err != nil {
+		return nil, err
+	}
+	podEnv, err := cel.NewEnv(
+		cel.Variable("object", cel.DynType),
+		cel.Variable("networks", cel.ListType(cel.DynType)),
+		cel.Variable("namespaceName", cel.StringType),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &ruleSet{}
+	names := map[string]struct{}{}
+	for _, rule := range ruleList {
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rule with expression %q has no name", rule.Expression)
+		}
+		if _, found := names[rule.Name]; found {
+			return nil, fmt.Errorf("rule %q is defined more than once", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		if rule.Severity == "" {
+			rule.Severity = RuleSeverityDeny
+		}
+		if rule.Severity != RuleSeverityDeny && rule.Severity != RuleSeverityWarn {
+			return nil, fmt.Errorf("rule %q has invalid severity %q", rule.Name, rule.Severity)
+		}
+
+		var env *cel.Env
+		switch rule.Target {
+		case RuleTargetNetworkAttachmentDefinition:
+			env = netAttachDefEnv
+		case RuleTargetPod:
+			env = podEnv
+		default:
+			return nil, fmt.Errorf("rule %q has invalid target %q", rule.Name, rule.Target)
+		}
+
+		ast, issues := env.Compile(rule.Expression)
+		if issues != nil && issues.Err() != nil {
+			return nil, fmt.Errorf("rule %q: %v", rule.Name, issues.Err())
+		}
+		if ast.OutputType() != cel.BoolType {
+			return nil, fmt.Errorf("rule %q: expression must evaluate to a bool, not %v", rule.Name, ast.OutputType())
+		}
+		cost, err := env.EstimateCost(ast, ruleCostEstimator{})
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: error estimating cost: %v", rule.Name, err)
+		}
+		if cost.Max > RuleCostLimit {
+			return nil, fmt.Errorf("rule %q: estimated cost %d exceeds the limit of %d", rule.Name, cost.Max, RuleCostLimit)
+		}
+		program, err := env.Program(ast, cel.CostLimit(RuleCostLimit))
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %v", rule.Name, err)
+		}
+
+		compiled := compiledRule{Rule: rule, program: program}
+		if rule.Target == RuleTargetPod {
+			set.pod = append(set.pod, compiled)
+		} else {
+			set.netAttachDef = append(set.netAttachDef, compiled)
+		}
+	}
+	return set, nil
+}
+
+// setRules compiles the rules and replaces the current ones if they are all valid
+func setRules(ruleList []Rule) error {
+	set, err := compileRules(ruleList)
+	if err != nil {
+		return err
+	}
+	rulesMutex.Lock()
+	defer rulesMutex.Unlock()
+	rules = set
+	return nil
+}
+
+func currentRules() *ruleSet {
+	rulesMutex.RLock()
+	defer rulesMutex.RUnlock()
+	return rules
+}
+
+// LoadRules reads and compiles the rules file, the current rules are kept on error
+func LoadRules(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file RuleFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return fmt.Errorf("error parsing rules file %s: %v", path, err)
+	}
+	if err := setRules(file.Rules); err != nil {
+		return fmt.Errorf("error compiling rules file %s: %v", path, err)
+	}
+	glog.Infof("loaded %d rules from %s", len(file.Rules), path)
+	configFileLoaded("rules")
+	return nil
+}
+
+// configFileLoaded records that the configuration file holding what was loaded
+func configFileLoaded(what string) {
+	loadedConfigFilesMutex.Lock()
+	defer loadedConfigFilesMutex.Unlock()
+	loadedConfigFiles[what] = true
+}
+
+// WatchRules reloads the rules file whenever it changes
+func WatchRules(path string, stopCh <-chan struct{}) error {
+	return watchFile(path, "rules", LoadRules, stopCh)
+}
+
+// watchFile calls load whenever the file changes. On error the current
+// configuration is kept, the error is logged and reported by the
+// config_last_reload_successful metric. The directory is watched, so that
+// files mounted from a ConfigMap are reloaded when their symlinks are swapped.
+func watchFile(path, what string, load func(string) error, stopCh <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return err
+	}
+	loadedConfigFilesMutex.Lock()
+	if !loadedConfigFiles[what] {
+		loadedConfigFiles[what] = false
+	}
+	loadedConfigFilesMutex.Unlock()
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event := <-watcher.Events:
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				err := load(path)
+				if err != nil {
+					glog.Errorf("keeping previous %s: %v", what, err)
+				}
+				localmetrics.SetConfigLastReloadSuccessful(what, err == nil)
+			case err := <-watcher.Errors:
+				glog.Errorf("error watching %s file %s: %v", what, path, err)
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// ruleError is returned for a failed Deny rule
+type ruleError struct {
+	rule    string
+	message string
+}
+
+func (e *ruleError) Error() string {
+	return fmt.Sprintf("denied by rule %q: %s", e.rule, e.message)
+}
+
+// toValue converts an object to the generic JSON representation used by CEL
+func toValue(obj interface{}) (interface{}, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
+
+// evaluateRules runs the rules, it returns the warnings of failed Warn rules
+// and an aggregate of a ruleError for each failed Deny rule
+func evaluateRules(ruleList []compiledRule, vars map[string]interface{}) ([]string, error) {
+	var warnings []string
+	var errs []error
+	for _, rule := range ruleList {
+		out, _, err := rule.program.Eval(vars)
+		passed := err == nil && out.Value() == true
+
+		if passed {
+			continue
+		}
+		message := rule.Message
+		if err != nil {
+			message = fmt.Sprintf("%s (%v)", message, err)
+		}
+		if rule.Severity == RuleSeverityWarn {
+			warnings = append(warnings, fmt.Sprintf("rule %q: %s", rule.Name, message))
+			continue
+		}
+		errs = append(errs, &ruleError{rule: rule.Name, message: message})
+	}
+	return warnings, utilerrors.NewAggregate(errs)
+}
+
+// validateNetAttachDefRules evaluates the net-attach-def rules
+func validateNetAttachDefRules(netAttachDef netv1.NetworkAttachmentDefinition, namespace string) ([]string, error) {
+	ruleList := currentRules().netAttachDef
+	if len(ruleList) == 0 {
+		return nil, nil
+	}
+
+	object, err := toValue(netAttachDef)
+	if err != nil {
+		return nil, err
+	}
+	// the rules are not evaluated on a config that does not parse, which is
+	// reported by the config check
+	config := map[string]interface{}{}
+	plugins := []interface{}{}
+	if netAttachDef.Spec.Config != "" {
+		if err := json.Unmarshal([]byte(netAttachDef.Spec.Config), &config); err != nil {
+			return nil, nil
+		}
+		pluginConfigs, err := getCNIPlugins([]byte(netAttachDef.Spec.Config))
+		if err != nil {
+			return nil, nil
+		}
+		for _, plugin := range pluginConfigs {
+			plugins = append(plugins, plugin.conf)
+		}
+	}
+
+	return evaluateRules(ruleList, map[string]interface{}{
+		"object":        object,
+		"config":        config,
+		"plugins":       plugins,
+		"namespaceName": namespace,
+	})
+}
+
+// validatePodRules evaluates the pod rules
+func validatePodRules(pod v1.Pod, namespace string) ([]string, error) {
+	ruleList := currentRules().pod
+	if len(ruleList) == 0 {
+		return nil, nil
+	}
+
+	object, err := toValue(pod)
+	if err != nil {
+		return nil, err
+	}
+	// the rules are not evaluated on a networks annotation that does not
+	// parse, which is reported by the isolation check
+	networks := []interface{}{}
+	if annotation := pod.GetAnnotations()[networksAnnotationKey]; annotation != "" {
+		elements, err := parsePodNetworkAnnotation(annotation, namespace)
+		if err != nil {
+			return nil, nil
+		}
+		value, err := toValue(elements)
+		if err != nil {
+			return nil, err
+		}
+		networks = value.([]interface{})
+	}
+
+	return evaluateRules(ruleList, map[string]interface{}{
+		"object":        object,
+		"networks":      networks,
+		"namespaceName": namespace,
+	})
+}
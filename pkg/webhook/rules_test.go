@@ -0,0 +1,198 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var _ = Describe("CEL rules", func() {
+	AfterEach(func() {
+		Expect(setRules(nil)).To(Succeed())
+	})
+
+	DescribeTable("compiling invalid rules",
+		func(rule Rule, message string) {
+			Expect(setRules([]Rule{rule})).To(MatchError(ContainSubstring(message)))
+		},
+		Entry("without a name", Rule{Target: RuleTargetPod, Expression: "true"}, "has no name"),
+		Entry("with an invalid target", Rule{Name: "r", Target: "Service", Expression: "true"}, "invalid target"),
+		Entry("with an invalid severity", Rule{Name: "r", Target: RuleTargetPod, Expression: "true", Severity: "Fatal"}, "invalid severity"),
+		Entry("with a syntax error", Rule{Name: "r", Target: RuleTargetPod, Expression: "networks.size() >"}, `rule "r"`),
+		Entry("with an unknown variable", Rule{Name: "r", Target: RuleTargetPod, Expression: "plugins.size() > 0"}, "undeclared reference"),
+		Entry("with a non bool result", Rule{Name: "r", Target: RuleTargetPod, Expression: "networks.size()"}, "must evaluate to a bool"),
+		Entry("with a cost above the limit", Rule{Name: "r", Target: RuleTargetPod, Expression: "networks.all(n, networks.all(m, n == m || n.name != m.name))"}, "exceeds the limit"),
+	)
+
+	It("should reject duplicate rule names", func() {
+		rule := Rule{Name: "r", Target: RuleTargetPod, Expression: "true"}
+		Expect(setRules([]Rule{rule, rule})).To(MatchError(ContainSubstring("more than once")))
+	})
+
+	Describe("net-attach-def rules", func() {
+		BeforeEach(func() {
+			Expect(setRules([]Rule{
+				{
+					Name:       "team-x-jumbo-frames",
+					Target:     RuleTargetNetworkAttachmentDefinition,
+					Expression: `namespaceName != "team-x" || plugins.all(p, has(p.mtu) && p.mtu == 9000)`,
+					Message:    "NADs in team-x must set mtu 9000",
+				},
+				{
+					Name:       "prefer-named-configs",
+					Target:     RuleTargetNetworkAttachmentDefinition,
+					Expression: `has(config.name)`,
+					Message:    "spec.config should have a name",
+					Severity:   RuleSeverityWarn,
+				},
+				{
+					Name:       "no-ipvlan",
+					Target:     RuleTargetPod,
+					Expression: `false`,
+					Message:    "pod rules are not evaluated for net-attach-defs",
+				},
+			})).To(Succeed())
+		})
+
+		It("should allow net-attach-defs passing the rules", func() {
+			warnings, err := validateNetAttachDefRules(newNetAttachDef(`{"name": "jumbo", "type": "macvlan", "mtu": 9000}`), "team-x")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should name the rule denying a net-attach-def", func() {
+			_, err := validateNetAttachDefRules(newNetAttachDef(`{"name": "small", "plugins": [{"type": "macvlan", "mtu": 1500}]}`), "team-x")
+			Expect(err).To(MatchError(`denied by rule "team-x-jumbo-frames": NADs in team-x must set mtu 9000`))
+		})
+
+		It("should return warnings of failed Warn rules", func() {
+			warnings, err := validateNetAttachDefRules(newNetAttachDef(`{"type": "macvlan"}`), "team-y")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ConsistOf(`rule "prefer-named-configs": spec.config should have a name`))
+		})
+
+		It("should leave malformed configs to the config check", func() {
+			warnings, err := validateNetAttachDefRules(newNetAttachDef(`{"type": "macvlan"`), "team-x")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+		})
+
+		It("should expose the net-attach-def object", func() {
+			Expect(setRules([]Rule{{
+				Name:       "name-prefix",
+				Target:     RuleTargetNetworkAttachmentDefinition,
+				Expression: `object.metadata.name.startsWith("net-")`,
+				Message:    "names must start with net-",
+			}})).To(Succeed())
+			_, err := validateNetAttachDefRules(newNetAttachDef(`{"type": "macvlan"}`), "default")
+			Expect(err).To(MatchError(ContainSubstring("name-prefix")))
+		})
+	})
+
+	Describe("pod rules", func() {
+		BeforeEach(func() {
+			Expect(setRules([]Rule{{
+				Name:       "local-interface-names",
+				Target:     RuleTargetPod,
+				Expression: `networks.all(n, !has(n.interface) || n.interface.startsWith("net"))`,
+				Message:    "interface names must start with net",
+			}})).To(Succeed())
+		})
+
+		It("should evaluate the network selection elements", func() {
+			_, err := validatePodRules(newPod(map[string]string{networksAnnotationKey: "a@net1,b@net2"}), "default")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = validatePodRules(newPod(map[string]string{networksAnnotationKey: "a@eth1"}), "default")
+			Expect(err).To(MatchError(ContainSubstring("local-interface-names")))
+		})
+
+		It("should allow pods without networks", func() {
+			_, err := validatePodRules(newPod(nil), "default")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should leave malformed annotations to the isolation check", func() {
+			_, err := validatePodRules(newPod(map[string]string{networksAnnotationKey: "a/b/c"}), "default")
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("rules file", func() {
+		var (
+			dir   string
+			path  string
+			stop  chan struct{}
+			write = func(content string) {
+				Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			}
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "rules")
+			Expect(err).NotTo(HaveOccurred())
+			path = filepath.Join(dir, "rules.yaml")
+			stop = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			close(stop)
+			os.RemoveAll(dir)
+		})
+
+		It("should be reloaded when it changes and keep the rules on errors", func() {
+			write(`
+rules:
+- name: deny-all
+  target: Pod
+  expression: "false"
+  message: no pods
+`)
+			Expect(LoadRules(path)).To(Succeed())
+			Expect(currentRules().pod).To(HaveLen(1))
+			Expect(WatchRules(path, stop)).To(Succeed())
+
+			reloadSuccessful := func() float64 {
+				metric := &dto.Metric{}
+				Expect(localmetrics.ConfigLastReloadSuccessful.WithLabelValues("rules").Write(metric)).To(Succeed())
+				return metric.GetGauge().GetValue()
+			}
+
+			write(`rules: [{name: broken, target: Pod, expression: "networks >"}]`)
+			Eventually(reloadSuccessful, 5*time.Second).Should(Equal(0.0))
+			Expect(currentRules().pod).To(HaveLen(1))
+			Expect(ConfigFilesLoaded()).To(Succeed())
+
+			write(`rules: []`)
+			Eventually(func() int { return len(currentRules().pod) }, 5*time.Second).Should(Equal(0))
+			Eventually(reloadSuccessful, 5*time.Second).Should(Equal(1.0))
+		})
+
+		It("should reject unknown fields", func() {
+			write(`rules: [{name: r, target: Pod, expresion: "true"}]`)
+			Expect(LoadRules(path)).To(MatchError(ContainSubstring("unknown field")))
+		})
+	})
+})
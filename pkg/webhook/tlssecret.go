@@ -0,0 +1,124 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+)
+
+// The key pair is read from the keys of kubernetes.io/tls secrets, or from the
+// keys of the secret created by hack/webhook-create-signed-cert.sh
+var (
+	secretCertKeys = []string{v1.TLSCertKey, "cert.pem"}
+	secretKeyKeys  = []string{v1.TLSPrivateKeyKey, "key.pem"}
+)
+
+type tlsSecretReloader struct {
+	servedKeyPair
+	secretKey string
+}
+
+// secretData returns the data of the first of the keys found in the secret
+func secretData(secret *v1.Secret, keys []string) ([]byte, error) {
+	for _, key := range keys {
+		if data, ok := secret.Data[key]; ok {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("secret has none of the keys %v", keys)
+}
+
+// update loads the key pair of the secret and replaces the current one if it
+// changed. The current key pair is kept if the secret does not hold a valid one.
+func (keyPair *tlsSecretReloader) update(obj interface{}) {
+	secret, ok := obj.(*v1.Secret)
+	if !ok {
+		return
+	}
+	newCert, err := func() (tls.Certificate, error) {
+		certPEM, err := secretData(secret, secretCertKeys)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		keyPEM, err := secretData(secret, secretKeyKeys)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		return tls.X509KeyPair(certPEM, keyPEM)
+	}()
+	if err != nil {
+		localmetrics.IncCertificateReloads(localmetrics.ReloadFailure)
+		glog.Errorf("keeping the current certificate: failed to load certificate from secret %s: %v", keyPair.secretKey, err)
+		return
+	}
+	if keyPair.swap(&newCert) {
+		localmetrics.IncCertificateReloads(localmetrics.ReloadSuccess)
+		glog.Infof("certificate loaded from secret %s", keyPair.secretKey)
+	}
+}
+
+// newTLSSecretReloader serves the key pair of the secret listed and watched by lw
+func newTLSSecretReloader(lw cache.ListerWatcher, secretKey string, stopCh <-chan struct{}) (tlsKeypairReloader, error) {
+	result := &tlsSecretReloader{
+		servedKeyPair: servedKeyPair{source: "secret " + secretKey},
+		secretKey:     secretKey,
+	}
+
+	informer := cache.NewSharedIndexInformer(lw, &v1.Secret{}, 0, cache.Indexers{})
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: result.update,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			result.update(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			glog.Warningf("secret %s was deleted, keeping the current certificate", secretKey)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	go informer.Run(stopCh)
+
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		return nil, fmt.Errorf("timed out waiting for secret %s to sync", secretKey)
+	}
+	result.certMutex.RLock()
+	defer result.certMutex.RUnlock()
+	if result.cert == nil {
+		return nil, fmt.Errorf("secret %s does not exist or does not hold a valid key pair", secretKey)
+	}
+	return result, nil
+}
+
+// NewTLSSecretReloader loads the TLS key pair from the secret namespace/name and
+// reloads it as soon as the secret changes, until stopCh is closed
+func NewTLSSecretReloader(secretKey string, stopCh <-chan struct{}) (tlsKeypairReloader, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid secret %q, must be namespace/name", secretKey)
+	}
+	lw := cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), "secrets", namespace, fields.OneTermEqualSelector("metadata.name", name))
+	return newTLSSecretReloader(lw, secretKey, stopCh)
+}
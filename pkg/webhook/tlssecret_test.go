@@ -0,0 +1,121 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+var _ = Describe("TLS secret reloader", func() {
+	var (
+		stopCh  chan struct{}
+		watcher *watch.FakeWatcher
+	)
+
+	newSecret := func(resourceVersion string, data map[string][]byte) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-tls", Namespace: "kube-system", ResourceVersion: resourceVersion},
+			Data:       data,
+		}
+	}
+
+	tlsSecret := func(resourceVersion, commonName string) *v1.Secret {
+		cert, key := newTestKeyPair(commonName)
+		return newSecret(resourceVersion, map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: key})
+	}
+
+	// listWatch lists the secrets and returns the fake watcher
+	listWatch := func(secrets ...v1.Secret) cache.ListerWatcher {
+		return &cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				return &v1.SecretList{ListMeta: metav1.ListMeta{ResourceVersion: "1"}, Items: secrets}, nil
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				return watcher, nil
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		stopCh = make(chan struct{})
+		watcher = watch.NewFake()
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+		localmetrics.CertificateReloads.Reset()
+	})
+
+	It("should fail when the secret does not exist", func() {
+		_, err := newTLSSecretReloader(listWatch(), "kube-system/webhook-tls", stopCh)
+		Expect(err).To(MatchError(ContainSubstring("does not exist")))
+	})
+
+	It("should fail when the secret has no key pair", func() {
+		_, err := newTLSSecretReloader(listWatch(*newSecret("1", nil)), "kube-system/webhook-tls", stopCh)
+		Expect(err).To(MatchError(ContainSubstring("does not hold a valid key pair")))
+		Expect(certificateReloads(localmetrics.ReloadFailure)).To(Equal(1.0))
+	})
+
+	It("should reject secret names without a namespace", func() {
+		_, err := NewTLSSecretReloader("webhook-tls", stopCh)
+		Expect(err).To(MatchError(ContainSubstring("must be namespace/name")))
+	})
+
+	It("should read the keys of the secret created by the install script", func() {
+		cert, key := newTestKeyPair("first")
+		secret := newSecret("1", map[string][]byte{"cert.pem": cert, "key.pem": key})
+		reloader, err := newTLSSecretReloader(listWatch(*secret), "kube-system/webhook-tls", stopCh)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(servedCommonName(reloader)).To(Equal("first"))
+	})
+
+	Context("with a secret", func() {
+		var reloader tlsKeypairReloader
+
+		BeforeEach(func() {
+			var err error
+			reloader, err = newTLSSecretReloader(listWatch(*tlsSecret("1", "first")), "kube-system/webhook-tls", stopCh)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(servedCommonName(reloader)).To(Equal("first"))
+		})
+
+		It("should swap the key pair when the secret changes", func() {
+			watcher.Modify(tlsSecret("2", "second"))
+			Eventually(func() string { return servedCommonName(reloader) }).Should(Equal("second"))
+			Expect(certificateReloads(localmetrics.ReloadSuccess)).To(Equal(2.0))
+		})
+
+		It("should keep the key pair when the secret is invalid or deleted", func() {
+			cert, _ := newTestKeyPair("second")
+			_, key := newTestKeyPair("second")
+			watcher.Modify(newSecret("2", map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: key}))
+			Eventually(func() float64 { return certificateReloads(localmetrics.ReloadFailure) }).Should(Equal(1.0))
+			Expect(servedCommonName(reloader)).To(Equal("first"))
+
+			watcher.Delete(tlsSecret("3", "third"))
+			watcher.Add(tlsSecret("4", "fourth"))
+			Eventually(func() string { return servedCommonName(reloader) }).Should(Equal("fourth"))
+		})
+	})
+})
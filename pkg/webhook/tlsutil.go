@@ -15,66 +15,240 @@
 package webhook
 
 import (
+	"bytes"
 	"crypto/tls"
-	"os"
-	"os/signal"
+	"crypto/x509"
+	"fmt"
+	"path/filepath"
 	"sync"
-	"syscall"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/golang/glog"
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+)
+
+var (
+	// certReloadSettleDelay gives the writer of the certificate time to write
+	// the key too before the pair is reloaded
+	certReloadSettleDelay = 100 * time.Millisecond
+	// certReloadInitialRetryDelay is the first delay before a failed reload is
+	// retried, it doubles up to certReloadMaxRetryDelay
+	certReloadInitialRetryDelay = time.Second
+	certReloadMaxRetryDelay     = time.Minute
 )
 
 type tlsKeypairReloader interface {
 	GetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error)
 }
 
-type tlsKeypairReloaderImpl struct {
+// defaultCipherSuites are the TLS 1.2 cipher suites allowed by default for the
+// key type of a certificate, ECDHE key exchange with AEAD ciphers only. TLS 1.3
+// cipher suites are not configurable.
+var defaultCipherSuites = map[x509.PublicKeyAlgorithm][]uint16{
+	x509.ECDSA: {
+		tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	},
+	x509.RSA: {
+		tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+	},
+}
+
+// DefaultCipherSuites returns the default cipher suites of all key types. The
+// server only negotiates the suites matching the key of the certificate it
+// selected for the client, so each key type gets its own safe list.
+func DefaultCipherSuites() []uint16 {
+	return append(append([]uint16{}, defaultCipherSuites[x509.ECDSA]...), defaultCipherSuites[x509.RSA]...)
+}
+
+// keyPairSet serves several key pairs, e.g. an RSA and an ECDSA one
+type keyPairSet []tlsKeypairReloader
+
+// GetCertificateFunc returns the first certificate supported by the client, or
+// the first certificate if the client supports none, so that the handshake
+// fails with a meaningful error
+func (set keyPairSet) GetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	getters := make([]func(*tls.ClientHelloInfo) (*tls.Certificate, error), len(set))
+	for i, reloader := range set {
+		getters[i] = reloader.GetCertificateFunc()
+	}
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		var first *tls.Certificate
+		for _, getCertificate := range getters {
+			cert, err := getCertificate(clientHello)
+			if err != nil || cert == nil {
+				continue
+			}
+			if clientHello.SupportsCertificate(cert) == nil {
+				return cert, nil
+			}
+			if first == nil {
+				first = cert
+			}
+		}
+		return first, nil
+	}
+}
+
+// newKeyPairSet returns the reloader itself if there is only one
+func newKeyPairSet(reloaders []tlsKeypairReloader) tlsKeypairReloader {
+	if len(reloaders) == 1 {
+		return reloaders[0]
+	}
+	return keyPairSet(reloaders)
+}
+
+// servedKeyPair holds the key pair served by a tlsKeypairReloader
+type servedKeyPair struct {
+	// source names the file or secret the key pair is loaded from
+	source    string
 	certMutex sync.RWMutex
 	cert      *tls.Certificate
-	certPath  string
-	keyPath   string
 }
 
+// swap replaces the served key pair, it returns false if the certificate did
+// not change
+func (served *servedKeyPair) swap(newCert *tls.Certificate) bool {
+	served.certMutex.Lock()
+	defer served.certMutex.Unlock()
+	if served.cert != nil && bytes.Equal(served.cert.Certificate[0], newCert.Certificate[0]) {
+		return false
+	}
+	served.cert = newCert
+	recordServedCertificate(served.source, newCert)
+	return true
+}
+
+func (served *servedKeyPair) GetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		served.certMutex.RLock()
+		defer served.certMutex.RUnlock()
+		return served.cert, nil
+	}
+}
+
+type tlsKeypairReloaderImpl struct {
+	servedKeyPair
+	certPath string
+	keyPath  string
+}
+
+// maybeReload loads the key pair and replaces the current one if it changed.
+// The current key pair is kept if the files do not hold a matching pair, e.g.
+// when only one of them has been written yet.
 func (keyPair *tlsKeypairReloaderImpl) maybeReload() error {
 	newCert, err := tls.LoadX509KeyPair(keyPair.certPath, keyPair.keyPath)
+	if err != nil {
+		localmetrics.IncCertificateReloads(localmetrics.ReloadFailure)
+		return fmt.Errorf("failed to reload certificate: %v", err)
+	}
+	if keyPair.swap(&newCert) {
+		localmetrics.IncCertificateReloads(localmetrics.ReloadSuccess)
+		glog.Infof("certificate reloaded from %s", keyPair.certPath)
+	}
+	return nil
+}
+
+// watchFiles calls reload when one of the files changes, what names their
+// content in logs. The directories are watched, so that files mounted from a
+// Secret or ConfigMap are reloaded when their symlinks are swapped.
+func watchFiles(what string, paths []string, reload func() error, stopCh <-chan struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
-	glog.Infoln("cetificate reloaded")
-	keyPair.certMutex.Lock()
-	defer keyPair.certMutex.Unlock()
-	keyPair.cert = &newCert
+	for _, path := range paths {
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return err
+		}
+	}
+	go runReloads(watcher, what, reload, stopCh)
 	return nil
 }
 
-func (keyPair *tlsKeypairReloaderImpl) GetCertificateFunc() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
-	return func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
-		keyPair.certMutex.RLock()
-		defer keyPair.certMutex.RUnlock()
-		return keyPair.cert, nil
+// runReloads calls reload shortly after the last change, and retries with an
+// increasing delay while it fails
+func runReloads(watcher *fsnotify.Watcher, what string, reload func() error, stopCh <-chan struct{}) {
+	defer watcher.Close()
+
+	var timer *time.Timer
+	var timerC <-chan time.Time
+	schedule := func(delay time.Duration) {
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.NewTimer(delay)
+		timerC = timer.C
+	}
+	retryDelay := certReloadInitialRetryDelay
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			retryDelay = certReloadInitialRetryDelay
+			schedule(certReloadSettleDelay)
+		case <-timerC:
+			timerC = nil
+			if err := reload(); err != nil {
+				glog.Errorf("keeping the current %s, retrying in %v: %v", what, retryDelay, err)
+				schedule(retryDelay)
+				retryDelay *= 2
+				if retryDelay > certReloadMaxRetryDelay {
+					retryDelay = certReloadMaxRetryDelay
+				}
+			}
+		case err := <-watcher.Errors:
+			glog.Errorf("error watching %s: %v", what, err)
+		case <-stopCh:
+			if timer != nil {
+				timer.Stop()
+			}
+			return
+		}
 	}
 }
 
-// NewTLSKeypairReloader reloads TLS keypairs
-func NewTLSKeypairReloader(certPath, keyPath string) (tlsKeypairReloader, error) {
+// NewTLSKeypairReloader loads the TLS key pair and reloads it whenever the
+// files change, until stopCh is closed
+func NewTLSKeypairReloader(certPath, keyPath string, stopCh <-chan struct{}) (tlsKeypairReloader, error) {
 	result := &tlsKeypairReloaderImpl{
-		certPath: certPath,
-		keyPath:  keyPath,
+		servedKeyPair: servedKeyPair{source: certPath},
+		certPath:      certPath,
+		keyPath:       keyPath,
 	}
 	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
 	if err != nil {
 		return nil, err
 	}
-	result.cert = &cert
+	result.swap(&cert)
 
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGHUP)
-		for range c {
-			if err := result.maybeReload(); err != nil {
-				glog.Fatalf("Failed to reload certificate: %v", err)
-			}
-		}
-	}()
+	if err := watchFiles("certificate", []string{certPath, keyPath}, result.maybeReload, stopCh); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
+
+// NewTLSKeypairReloaders loads the TLS key pairs of the matching certificate
+// and key files, and serves the one supported by each client
+func NewTLSKeypairReloaders(certPaths, keyPaths []string, stopCh <-chan struct{}) (tlsKeypairReloader, error) {
+	if len(certPaths) == 0 || len(certPaths) != len(keyPaths) {
+		return nil, fmt.Errorf("%d certificate files for %d key files", len(certPaths), len(keyPaths))
+	}
+	reloaders := make([]tlsKeypairReloader, len(certPaths))
+	for i := range certPaths {
+		reloader, err := NewTLSKeypairReloader(certPaths[i], keyPaths[i], stopCh)
+		if err != nil {
+			return nil, err
+		}
+		reloaders[i] = reloader
+	}
+	return newKeyPairSet(reloaders), nil
+}
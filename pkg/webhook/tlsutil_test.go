@@ -0,0 +1,286 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k8snetworkplumbingwg/net-attach-def-admission-controller/pkg/localmetrics"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// newTestKeyPair returns a PEM encoded self-signed ECDSA certificate and its key,
+// the common name tells certificates apart
+func newTestKeyPair(commonName string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	return newTestKeyPairWithKey(commonName, key)
+}
+
+// newTestKeyPairWithKey returns a PEM encoded self-signed certificate for the
+// key, valid for the common name and the name of a webhook service
+func newTestKeyPairWithKey(commonName string, key crypto.Signer) ([]byte, []byte) {
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName, "webhook.kube-system.svc"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+}
+
+// servedCommonName returns the common name of the certificate the reloader serves
+func servedCommonName(reloader tlsKeypairReloader) string {
+	cert, err := reloader.GetCertificateFunc()(&tls.ClientHelloInfo{})
+	Expect(err).NotTo(HaveOccurred())
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	Expect(err).NotTo(HaveOccurred())
+	return leaf.Subject.CommonName
+}
+
+// certificateReloads returns the value of the reload counter
+func certificateReloads(result string) float64 {
+	metric := &dto.Metric{}
+	Expect(localmetrics.CertificateReloads.WithLabelValues(result).Write(metric)).To(Succeed())
+	return metric.GetCounter().GetValue()
+}
+
+var _ = Describe("TLS key pair reloader", func() {
+	var (
+		dir      string
+		certPath string
+		keyPath  string
+		stopCh   chan struct{}
+	)
+
+	write := func(path string, data []byte) {
+		tmp := path + ".tmp"
+		Expect(os.WriteFile(tmp, data, 0600)).To(Succeed())
+		Expect(os.Rename(tmp, path)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tls")
+		Expect(err).NotTo(HaveOccurred())
+		certPath = filepath.Join(dir, "cert.pem")
+		keyPath = filepath.Join(dir, "key.pem")
+		stopCh = make(chan struct{})
+		certReloadSettleDelay = 10 * time.Millisecond
+		certReloadInitialRetryDelay = 10 * time.Millisecond
+	})
+
+	AfterEach(func() {
+		close(stopCh)
+		os.RemoveAll(dir)
+		certReloadSettleDelay = 100 * time.Millisecond
+		certReloadInitialRetryDelay = time.Second
+		localmetrics.CertificateReloads.Reset()
+	})
+
+	It("should fail without a valid key pair", func() {
+		_, err := NewTLSKeypairReloader(certPath, keyPath, stopCh)
+		Expect(err).To(HaveOccurred())
+	})
+
+	Context("with files", func() {
+		var reloader tlsKeypairReloader
+
+		BeforeEach(func() {
+			cert, key := newTestKeyPair("first")
+			write(certPath, cert)
+			write(keyPath, key)
+			var err error
+			reloader, err = NewTLSKeypairReloader(certPath, keyPath, stopCh)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(servedCommonName(reloader)).To(Equal("first"))
+		})
+
+		It("should reload a changed key pair", func() {
+			cert, key := newTestKeyPair("second")
+			write(keyPath, key)
+			write(certPath, cert)
+			Eventually(func() string { return servedCommonName(reloader) }).Should(Equal("second"))
+			Expect(certificateReloads(localmetrics.ReloadSuccess)).To(Equal(1.0))
+		})
+
+		It("should keep the current key pair until the files match", func() {
+			cert, key := newTestKeyPair("second")
+			write(certPath, cert)
+			Eventually(func() float64 { return certificateReloads(localmetrics.ReloadFailure) }).Should(BeNumerically(">=", 1))
+			Expect(servedCommonName(reloader)).To(Equal("first"))
+
+			write(keyPath, key)
+			Eventually(func() string { return servedCommonName(reloader) }).Should(Equal("second"))
+		})
+	})
+
+	It("should retry until the key pair is valid", func() {
+		// the files are symlinks to an unwatched directory, so that only the
+		// retries notice when the key is fixed
+		target, err := os.MkdirTemp("", "tls-target")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.RemoveAll(target)
+		cert, key := newTestKeyPair("first")
+		write(filepath.Join(target, "cert.pem"), cert)
+		write(filepath.Join(target, "key.pem"), key)
+		Expect(os.Symlink(filepath.Join(target, "cert.pem"), certPath)).To(Succeed())
+		Expect(os.Symlink(filepath.Join(target, "key.pem"), keyPath)).To(Succeed())
+		reloader, err := NewTLSKeypairReloader(certPath, keyPath, stopCh)
+		Expect(err).NotTo(HaveOccurred())
+
+		cert, key = newTestKeyPair("second")
+		write(filepath.Join(target, "cert.pem"), cert)
+		Expect(os.WriteFile(filepath.Join(dir, "trigger"), nil, 0600)).To(Succeed())
+		Eventually(func() float64 { return certificateReloads(localmetrics.ReloadFailure) }).Should(BeNumerically(">=", 2))
+
+		write(filepath.Join(target, "key.pem"), key)
+		Eventually(func() string { return servedCommonName(reloader) }).Should(Equal("second"))
+	})
+
+	It("should reload when a secret volume swaps its data symlink", func() {
+		// kubelet writes secrets to a timestamped directory and swaps the ..data
+		// symlink to it, the files are symlinks into ..data
+		writeVersion := func(version, commonName string) {
+			cert, key := newTestKeyPair(commonName)
+			Expect(os.Mkdir(filepath.Join(dir, version), 0700)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, version, "cert.pem"), cert, 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, version, "key.pem"), key, 0600)).To(Succeed())
+			Expect(os.Symlink(version, filepath.Join(dir, "..data_tmp"))).To(Succeed())
+			Expect(os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data"))).To(Succeed())
+		}
+		writeVersion("..v1", "first")
+		Expect(os.Symlink("..data/cert.pem", certPath)).To(Succeed())
+		Expect(os.Symlink("..data/key.pem", keyPath)).To(Succeed())
+		reloader, err := NewTLSKeypairReloader(certPath, keyPath, stopCh)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(servedCommonName(reloader)).To(Equal("first"))
+
+		writeVersion("..v2", "second")
+		Eventually(func() string { return servedCommonName(reloader) }).Should(Equal("second"))
+	})
+})
+
+var _ = Describe("TLS key pair set", func() {
+	var (
+		dir    string
+		stopCh chan struct{}
+		server *httptest.Server
+	)
+
+	writeKeyPair := func(name string, key crypto.Signer) (string, string) {
+		cert, keyPEM := newTestKeyPairWithKey(name, key)
+		certPath, keyPath := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+		Expect(os.WriteFile(certPath, cert, 0600)).To(Succeed())
+		Expect(os.WriteFile(keyPath, keyPEM, 0600)).To(Succeed())
+		return certPath, keyPath
+	}
+
+	// handshake connects with the TLS 1.2 cipher suites and returns the served
+	// certificate. The server name is set like the API server does, otherwise
+	// the server serves the certificate of httptest.
+	handshake := func(cipherSuites []uint16) (*x509.Certificate, error) {
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{
+			ServerName:         "webhook.kube-system.svc",
+			InsecureSkipVerify: true,
+			MaxVersion:         tls.VersionTLS12,
+			CipherSuites:       cipherSuites,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0], nil
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tls-set")
+		Expect(err).NotTo(HaveOccurred())
+		stopCh = make(chan struct{})
+
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+		ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		rsaCert, rsaKeyPath := writeKeyPair("rsa", rsaKey)
+		ecdsaCert, ecdsaKeyPath := writeKeyPair("ecdsa", ecdsaKey)
+
+		reloader, err := NewTLSKeypairReloaders([]string{rsaCert, ecdsaCert}, []string{rsaKeyPath, ecdsaKeyPath}, stopCh)
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+		server.TLS = &tls.Config{
+			GetCertificate: reloader.GetCertificateFunc(),
+			CipherSuites:   DefaultCipherSuites(),
+		}
+		server.StartTLS()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		close(stopCh)
+		os.RemoveAll(dir)
+	})
+
+	It("should require a key file for each certificate file", func() {
+		_, err := NewTLSKeypairReloaders([]string{"a.crt", "b.crt"}, []string{"a.key"}, stopCh)
+		Expect(err).To(MatchError("2 certificate files for 1 key files"))
+	})
+
+	It("should serve the certificate matching the cipher suites of the client", func() {
+		cert, err := handshake(defaultCipherSuites[x509.RSA])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.Subject.CommonName).To(Equal("rsa"))
+
+		cert, err = handshake(defaultCipherSuites[x509.ECDSA])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.Subject.CommonName).To(Equal("ecdsa"))
+
+		cert, err = handshake(nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cert.Subject.CommonName).To(Equal("rsa"))
+	})
+
+	It("should only negotiate the default cipher suites", func() {
+		_, err := handshake([]uint16{tls.TLS_RSA_WITH_AES_128_GCM_SHA256})
+		Expect(err).To(HaveOccurred())
+	})
+})
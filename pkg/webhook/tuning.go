@@ -0,0 +1,201 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	tuningPluginType = "tuning"
+	bridgePluginType = "bridge"
+	// minMTU is the smallest MTU usable with IPv4
+	minMTU = 68
+	// DefaultMaxMTU is the largest MTU allowed unless a policy sets another limit
+	DefaultMaxMTU = 9000
+)
+
+var (
+	// DefaultAllowedSysctls are the Kubernetes safe sysctls plus the per-interface net.* keys
+	DefaultAllowedSysctls = []string{
+		"kernel.shm_rmid_forced",
+		"net.ipv4.ip_local_port_range",
+		"net.ipv4.ip_local_reserved_ports",
+		"net.ipv4.ip_unprivileged_port_start",
+		"net.ipv4.ping_group_range",
+		"net.ipv4.tcp_fin_timeout",
+		"net.ipv4.tcp_keepalive_intvl",
+		"net.ipv4.tcp_keepalive_probes",
+		"net.ipv4.tcp_keepalive_time",
+		"net.ipv4.tcp_rmem",
+		"net.ipv4.tcp_syncookies",
+		"net.ipv4.tcp_wmem",
+		"net.ipv4.conf.*",
+		"net.ipv4.neigh.*",
+		"net.ipv6.conf.*",
+		"net.ipv6.neigh.*",
+	}
+	// DefaultDeniedSysctls exclude the keys that apply to all interfaces from the per-interface keys
+	DefaultDeniedSysctls = []string{
+		"net.ipv4.conf.all.*",
+		"net.ipv4.conf.default.*",
+		"net.ipv4.neigh.default.*",
+		"net.ipv6.conf.all.*",
+		"net.ipv6.conf.default.*",
+		"net.ipv6.neigh.default.*",
+	}
+)
+
+// TuningRestrictions configures the checks of sysctls and host affecting plugin options
+type TuningRestrictions struct {
+	// Enabled turns the checks on
+	Enabled bool
+	// AllowedSysctls lists the sysctl names or glob patterns the tuning plugin may set
+	AllowedSysctls []string
+	// DeniedSysctls lists sysctl names or glob patterns that are denied even if allowed
+	DeniedSysctls []string
+	// MaxMTU is the largest MTU a plugin may set, unless a policy sets another limit
+	MaxMTU int
+}
+
+var tuningRestrictions = TuningRestrictions{
+	AllowedSysctls: DefaultAllowedSysctls,
+	DeniedSysctls:  DefaultDeniedSysctls,
+	MaxMTU:         DefaultMaxMTU,
+}
+
+// SetTuningRestrictions configures the checks of sysctls and host affecting plugin options
+func SetTuningRestrictions(restrictions TuningRestrictions) {
+	tuningRestrictions = restrictions
+}
+
+// isSysctlAllowed checks the sysctl against the allow and deny lists,
+// both the dot and the slash separated notation are accepted
+func isSysctlAllowed(name string) bool {
+	name = strings.ReplaceAll(name, "/", ".")
+	return matchesAny(tuningRestrictions.AllowedSysctls, name) && !matchesAny(tuningRestrictions.DeniedSysctls, name)
+}
+
+// policiesMaxMTU returns the largest MTU permitted in a namespace, the lowest
+// maxMTU of the policies that set one, the default limit otherwise
+func policiesMaxMTU(policies []*NetworkAttachmentPolicy) int {
+	maxMTU := 0
+	for _, policy := range policies {
+		if policy.Spec.MaxMTU > 0 && (maxMTU == 0 || policy.Spec.MaxMTU < maxMTU) {
+			maxMTU = policy.Spec.MaxMTU
+		}
+	}
+	if maxMTU == 0 {
+		return tuningRestrictions.MaxMTU
+	}
+	return maxMTU
+}
+
+// policiesAllowPromisc checks that every policy of the namespace permits
+// promiscuous mode
+func policiesAllowPromisc(policies []*NetworkAttachmentPolicy) bool {
+	for _, policy := range policies {
+		if !policy.Spec.AllowPromisc {
+			return false
+		}
+	}
+	return len(policies) > 0
+}
+
+// policiesAllowAllmulti checks that every policy of the namespace permits
+// all-multicast mode
+func policiesAllowAllmulti(policies []*NetworkAttachmentPolicy) bool {
+	for _, policy := range policies {
+		if !policy.Spec.AllowAllmulti {
+			return false
+		}
+	}
+	return len(policies) > 0
+}
+
+// tuningViolations returns an error for each sysctl and host affecting option
+// of a plugin that is not permitted by the policies
+func tuningViolations(plugin cniPlugin, policies []*NetworkAttachmentPolicy, namespace string) field.ErrorList {
+	var allErrs field.ErrorList
+	pluginType, _ := plugin.conf["type"].(string)
+	notAllowed := fmt.Sprintf("not allowed in namespace %s", namespace)
+
+	if pluginType == tuningPluginType {
+		if sysctls, ok := plugin.conf["sysctl"].(map[string]interface{}); ok {
+			names := make([]string, 0, len(sysctls))
+			for name := range sysctls {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if !isSysctlAllowed(name) {
+					allErrs = append(allErrs, field.Forbidden(plugin.path.Child("sysctl").Key(name), "sysctl is "+notAllowed))
+				}
+			}
+		}
+		if promisc, _ := plugin.conf["promisc"].(bool); promisc && !policiesAllowPromisc(policies) {
+			allErrs = append(allErrs, field.Forbidden(plugin.path.Child("promisc"), "promiscuous mode is "+notAllowed))
+		}
+		if allmulti, _ := plugin.conf["allmulti"].(bool); allmulti && !policiesAllowAllmulti(policies) {
+			allErrs = append(allErrs, field.Forbidden(plugin.path.Child("allmulti"), "all-multicast mode is "+notAllowed))
+		}
+	}
+
+	// the bridge plugin puts the host bridge in promiscuous mode
+	if pluginType == bridgePluginType {
+		if promisc, _ := plugin.conf["promiscMode"].(bool); promisc && !policiesAllowPromisc(policies) {
+			allErrs = append(allErrs, field.Forbidden(plugin.path.Child("promiscMode"), "promiscuous mode is "+notAllowed))
+		}
+	}
+
+	if mtu, ok := plugin.conf["mtu"].(float64); ok {
+		if maxMTU := policiesMaxMTU(policies); mtu < minMTU {
+			allErrs = append(allErrs, field.Invalid(plugin.path.Child("mtu"), mtu, fmt.Sprintf("must be at least %d", minMTU)))
+		} else if maxMTU > 0 && mtu > float64(maxMTU) {
+			allErrs = append(allErrs, field.Invalid(plugin.path.Child("mtu"), mtu, fmt.Sprintf("must not exceed %d in namespace %s", maxMTU, namespace)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateTuningRestrictions checks sysctls and host affecting plugin options of the net-attach-def.
+// Configs that cannot be parsed are left to validateNetworkAttachmentDefinition.
+func validateTuningRestrictions(netAttachDef netv1.NetworkAttachmentDefinition, namespace string) (bool, error) {
+	if !tuningRestrictions.Enabled || netAttachDef.Spec.Config == "" {
+		return true, nil
+	}
+
+	plugins, err := getCNIPlugins([]byte(netAttachDef.Spec.Config))
+	if err != nil {
+		return true, nil
+	}
+
+	policies := policiesForNamespace(namespace)
+	var allErrs field.ErrorList
+	for _, plugin := range plugins {
+		allErrs = append(allErrs, tuningViolations(plugin, policies, namespace)...)
+	}
+	if len(allErrs) > 0 {
+		return false, allErrs.ToAggregate()
+	}
+
+	return true, nil
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2026 Network Plumbing Working Group
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Tuning restrictions", func() {
+	BeforeEach(func() {
+		SetTuningRestrictions(TuningRestrictions{
+			Enabled:        true,
+			AllowedSysctls: DefaultAllowedSysctls,
+			DeniedSysctls:  DefaultDeniedSysctls,
+			MaxMTU:         DefaultMaxMTU,
+		})
+		setPolicies(
+			[]*v1.Namespace{newNamespace("infra", nil), newNamespace("tenant", nil)},
+			newNetworkAttachmentPolicy("infra", NetworkAttachmentPolicySpec{
+				Namespaces:    []string{"infra"},
+				AllowPromisc:  true,
+				AllowAllmulti: true,
+				MaxMTU:        9216,
+			}),
+		)
+	})
+
+	AfterEach(func() {
+		SetTuningRestrictions(TuningRestrictions{
+			AllowedSysctls: DefaultAllowedSysctls,
+			DeniedSysctls:  DefaultDeniedSysctls,
+			MaxMTU:         DefaultMaxMTU,
+		})
+		resetPolicies()
+	})
+
+	DescribeTable("sysctls",
+		func(name string, allowed bool) {
+			Expect(isSysctlAllowed(name)).To(Equal(allowed))
+		},
+		Entry("safe sysctl", "net.ipv4.ip_local_port_range", true),
+		Entry("per-interface sysctl", "net.ipv4.conf.IFNAME.arp_filter", true),
+		Entry("per-interface sysctl in slash notation", "net/ipv6/conf/net1/accept_ra", true),
+		Entry("per-interface neighbour sysctl", "net.ipv4.neigh.net1.base_reachable_time_ms", true),
+		Entry("all interfaces sysctl", "net.ipv4.conf.all.rp_filter", false),
+		Entry("default interface sysctl", "net.ipv6.conf.default.forwarding", false),
+		Entry("unsafe sysctl", "net.ipv4.ip_forward", false),
+		Entry("kernel sysctl", "kernel.panic", false),
+	)
+
+	DescribeTable("validating net-attach-defs",
+		func(namespace, config string, out bool, violation string) {
+			allowed, err := validateTuningRestrictions(newNetAttachDef(config), namespace)
+			Expect(allowed).To(Equal(out))
+			if out {
+				Expect(err).NotTo(HaveOccurred())
+			} else {
+				Expect(err).To(MatchError(ContainSubstring(violation)))
+			}
+		},
+		Entry("safe sysctls", "tenant",
+			`{"plugins": [{"type": "macvlan"}, {"type": "tuning", "sysctl": {"net.ipv4.conf.IFNAME.arp_notify": "1", "net.ipv4.tcp_syncookies": "1"}}]}`,
+			true, ""),
+		Entry("unsafe sysctls", "tenant",
+			`{"plugins": [{"type": "macvlan"}, {"type": "tuning", "sysctl": {"net.ipv4.ip_forward": "1", "net.ipv4.conf.all.forwarding": "1"}}]}`,
+			false, "[spec.config.plugins[1].sysctl[net.ipv4.conf.all.forwarding]: Forbidden: sysctl is not allowed in namespace tenant, spec.config.plugins[1].sysctl[net.ipv4.ip_forward]: Forbidden"),
+		Entry("sysctls of other plugins", "tenant",
+			`{"type": "another-plugin", "sysctl": {"net.ipv4.conf.all.log_martians": "1"}}`,
+			true, ""),
+		Entry("promisc", "tenant", `{"type": "tuning", "promisc": true}`, false, "spec.config.promisc: Forbidden"),
+		Entry("promisc disabled", "tenant", `{"type": "tuning", "promisc": false}`, true, ""),
+		Entry("promisc in a permitted namespace", "infra", `{"type": "tuning", "promisc": true}`, true, ""),
+		Entry("bridge promiscMode", "tenant", `{"type": "bridge", "promiscMode": true}`, false, "spec.config.promiscMode: Forbidden"),
+		Entry("allmulti", "tenant", `{"type": "tuning", "allmulti": true}`, false, "spec.config.allmulti: Forbidden"),
+		Entry("allmulti in a permitted namespace", "infra", `{"type": "tuning", "allmulti": true}`, true, ""),
+		Entry("mtu within the limit", "tenant", `{"type": "macvlan", "mtu": 9000}`, true, ""),
+		Entry("mtu beyond the limit", "tenant", `{"type": "tuning", "mtu": 9216}`, false, "spec.config.mtu: Invalid value: 9216: must not exceed 9000"),
+		Entry("mtu below the minimum", "tenant", `{"type": "tuning", "mtu": 10}`, false, "spec.config.mtu: Invalid value: 10: must be at least 68"),
+		Entry("mtu within the namespace limit", "infra", `{"type": "tuning", "mtu": 9216}`, true, ""),
+	)
+
+	Context("with several policies in a namespace", func() {
+		BeforeEach(func() {
+			setPolicies(
+				[]*v1.Namespace{newNamespace("infra", nil)},
+				newNetworkAttachmentPolicy("infra", NetworkAttachmentPolicySpec{
+					Namespaces:    []string{"infra"},
+					AllowPromisc:  true,
+					AllowAllmulti: true,
+					MaxMTU:        9216,
+				}),
+				newNetworkAttachmentPolicy("infra-strict", NetworkAttachmentPolicySpec{
+					Namespaces: []string{"infra"},
+					MaxMTU:     1500,
+				}),
+				newNetworkAttachmentPolicy("infra-types", NetworkAttachmentPolicySpec{
+					Namespaces:        []string{"infra"},
+					DeniedPluginTypes: []string{"sriov"},
+				}),
+			)
+		})
+
+		DescribeTable("the most restrictive policy wins",
+			func(config, violation string) {
+				_, err := validateTuningRestrictions(newNetAttachDef(config), "infra")
+				Expect(err).To(MatchError(ContainSubstring(violation)))
+			},
+			Entry("promisc", `{"type": "tuning", "promisc": true}`, "spec.config.promisc: Forbidden"),
+			Entry("allmulti", `{"type": "tuning", "allmulti": true}`, "spec.config.allmulti: Forbidden"),
+			Entry("mtu", `{"type": "tuning", "mtu": 9000}`, "must not exceed 1500"),
+		)
+	})
+
+	It("should name the namespace in the error", func() {
+		_, err := validateTuningRestrictions(newNetAttachDef(`{"type": "tuning", "promisc": true}`), "tenant")
+		Expect(err).To(MatchError("spec.config.promisc: Forbidden: promiscuous mode is not allowed in namespace tenant"))
+	})
+
+	Context("when disabled", func() {
+		BeforeEach(func() {
+			SetTuningRestrictions(TuningRestrictions{})
+		})
+
+		It("should allow any option", func() {
+			allowed, err := validateTuningRestrictions(newNetAttachDef(`{"type": "tuning", "promisc": true, "sysctl": {"kernel.panic": "1"}}`), "tenant")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		})
+	})
+})
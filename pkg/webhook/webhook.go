@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
@@ -27,12 +28,13 @@ import (
 	"github.com/golang/glog"
 	"gopkg.in/k8snetworkplumbingwg/multus-cni.v4/pkg/types"
 	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	netattachdefClientset "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned"
 	"github.com/pkg/errors"
 	admissionv1 "k8s.io/api/admission/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
@@ -44,44 +46,153 @@ type jsonPatchOperation struct {
 }
 
 const (
-	networksAnnotationKey  = "k8s.v1.cni.cncf.io/networks"
-	networkResourceNameKey = "k8s.v1.cni.cncf.io/resourceName"
-	namespaceConstraint    = "_local"
+	networksAnnotationKey       = "k8s.v1.cni.cncf.io/networks"
+	defaultNetworkAnnotationKey = "v1.multus-cni.io/default-network"
+	networkResourceNameKey      = "k8s.v1.cni.cncf.io/resourceName"
+	namespaceConstraint         = "_local"
 )
 
 var (
-	clientset kubernetes.Interface
+	namePath        = field.NewPath("metadata", "name")
+	namespacePath   = field.NewPath("metadata", "namespace")
+	annotationsPath = field.NewPath("metadata", "annotations")
+	configPath      = field.NewPath("spec", "config")
 )
 
+var (
+	clientset     kubernetes.Interface
+	nadClientset  netattachdefClientset.Interface
+	dynamicClient dynamic.Interface
+	// defaultNetworkNamespaces lists the namespaces whose pods may override
+	// the default network. An empty list allows every namespace.
+	defaultNetworkNamespaces []string
+)
+
+// SetDefaultNetworkNamespaces restricts the default-network annotation to
+// pods in the given namespaces
+func SetDefaultNetworkNamespaces(namespaces []string) {
+	defaultNetworkNamespaces = namespaces
+}
+
 // validateCNIConfig verifies following fields
 // conf: 'type'
 // conflist: 'plugins' and 'type'
+// and the IPAM subnets of every plugin
 func validateCNIConfig(config []byte) error {
 	var c map[string]interface{}
 	if err := json.Unmarshal(config, &c); err != nil {
-		return err
+		return field.ErrorList{field.Invalid(configPath, field.OmitValueType{}, jsonErrorDetail(err))}.ToAggregate()
 	}
 
+	var allErrs field.ErrorList
 	// Identify target is single CNI config or plugins
 	if p, ok := c["plugins"]; ok {
 		// CNI conflist
 		// check 'type' field for each plugin in 'plugins'
-		plugins := p.([]interface{})
-		for _, v := range plugins {
-			plugin := v.(map[string]interface{})
-			if _, ok := plugin["type"]; !ok {
-				return fmt.Errorf("missing 'type' in plugins")
+		pluginsPath := configPath.Child("plugins")
+		plugins, ok := p.([]interface{})
+		if !ok {
+			return field.ErrorList{field.Invalid(pluginsPath, p, "must be a list")}.ToAggregate()
+		}
+		for i, v := range plugins {
+			plugin, ok := v.(map[string]interface{})
+			if !ok {
+				allErrs = append(allErrs, field.Invalid(pluginsPath.Index(i), v, "must be an object"))
+				continue
 			}
+			allErrs = append(allErrs, validatePluginConfig(plugin, pluginsPath.Index(i))...)
 		}
 	} else {
 		// single CNI config
-		if _, ok := c["type"]; !ok {
-			return fmt.Errorf("missing 'type' in cni config")
+		allErrs = append(allErrs, validatePluginConfig(c, configPath)...)
+	}
+	return allErrs.ToAggregate()
+}
+
+// validatePluginConfig verifies the type and the IPAM subnets of a plugin
+func validatePluginConfig(plugin map[string]interface{}, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if t, ok := plugin["type"]; !ok {
+		allErrs = append(allErrs, field.Required(path.Child("type"), "missing 'type' in cni config"))
+	} else if _, ok := t.(string); !ok {
+		allErrs = append(allErrs, field.Invalid(path.Child("type"), t, "must be a string"))
+	}
+
+	ipam, ok := plugin["ipam"].(map[string]interface{})
+	if !ok {
+		return allErrs
+	}
+	ipamPath := path.Child("ipam")
+	allErrs = append(allErrs, validateSubnet(ipam["subnet"], ipamPath.Child("subnet"))...)
+	// host-local takes a list of range sets
+	if ranges, ok := ipam["ranges"].([]interface{}); ok {
+		for i, r := range ranges {
+			rangeSet, _ := r.([]interface{})
+			for j, entry := range rangeSet {
+				if ipRange, ok := entry.(map[string]interface{}); ok {
+					allErrs = append(allErrs, validateSubnet(ipRange["subnet"], ipamPath.Child("ranges").Index(i).Index(j).Child("subnet"))...)
+				}
+			}
 		}
 	}
+	return allErrs
+}
+
+// validateSubnet verifies that an optional subnet is in CIDR notation
+func validateSubnet(value interface{}, path *field.Path) field.ErrorList {
+	if value == nil {
+		return nil
+	}
+	subnet, ok := value.(string)
+	if !ok {
+		return field.ErrorList{field.Invalid(path, value, "must be a string")}
+	}
+	if _, _, err := net.ParseCIDR(subnet); err != nil {
+		return field.ErrorList{field.Invalid(path, subnet, err.Error())}
+	}
 	return nil
 }
 
+// jsonErrorDetail returns the JSON parser error with the offset of syntax errors
+func jsonErrorDetail(err error) string {
+	if syntaxErr, ok := err.(*json.SyntaxError); ok {
+		return fmt.Sprintf("%v (at offset %d)", syntaxErr, syntaxErr.Offset)
+	}
+	return err.Error()
+}
+
+// cniPlugin is a plugin configuration and its field path in the net-attach-def
+type cniPlugin struct {
+	conf map[string]interface{}
+	path *field.Path
+}
+
+// getCNIPlugins returns the plugin configurations of a CNI config or conflist
+func getCNIPlugins(config []byte) ([]cniPlugin, error) {
+	var c map[string]interface{}
+	if err := json.Unmarshal(config, &c); err != nil {
+		return nil, err
+	}
+
+	p, ok := c["plugins"]
+	if !ok {
+		return []cniPlugin{{conf: c, path: configPath}}, nil
+	}
+	list, ok := p.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'plugins' in cni config is not a list")
+	}
+	plugins := make([]cniPlugin, 0, len(list))
+	for i, v := range list {
+		plugin, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("plugin in cni config is not an object")
+		}
+		plugins = append(plugins, cniPlugin{conf: plugin, path: configPath.Child("plugins").Index(i)})
+	}
+	return plugins, nil
+}
+
 // preprocessCNIConfig process CNI config bytes as following (that multus does too)
 // - if 'name' is missing, 'name' is filled
 func preprocessCNIConfig(name string, config []byte) ([]byte, error) {
@@ -95,24 +206,18 @@ func preprocessCNIConfig(name string, config []byte) ([]byte, error) {
 	return configBytes, err
 }
 
-// isJSON detects if a string is in JSON format
-func isJSON(s string) bool {
-	var js map[string]interface{}
-	return json.Unmarshal([]byte(s), &js) == nil
-}
-
 func validateNetworkAttachmentDefinition(netAttachDef netv1.NetworkAttachmentDefinition) (bool, error) {
+	var allErrs field.ErrorList
+
 	nameRegex := `^[a-z-1-9]([-a-z0-9]*[a-z0-9])?$`
 	isNameCorrect, err := regexp.MatchString(nameRegex, netAttachDef.GetName())
-	if !isNameCorrect {
-		err := errors.New("net-attach-def name is invalid")
-		glog.Info(err)
-		return false, err
-	}
 	if err != nil {
-		err := errors.New("error validating name")
-		glog.Error(err)
-		return false, err
+		glog.Errorf("error validating name: %v", err)
+		return false, field.ErrorList{field.InternalError(namePath, err)}.ToAggregate()
+	}
+	if !isNameCorrect {
+		glog.Infof("net-attach-def name %q is invalid", netAttachDef.GetName())
+		allErrs = append(allErrs, field.Invalid(namePath, netAttachDef.GetName(), "net-attach-def name is invalid, must match "+nameRegex))
 	}
 
 	glog.Infof("validating network config spec: %s", netAttachDef.Spec.Config)
@@ -122,36 +227,39 @@ func validateNetworkAttachmentDefinition(netAttachDef netv1.NetworkAttachmentDef
 		// try to unmarshal config into NetworkConfig or NetworkConfigList
 		//  using actual code from libcni - if succesful, it means that the config
 		//  will be accepted by CNI itself as well
-		if !isJSON(netAttachDef.Spec.Config) {
-			err := errors.New("configuration string is not in JSON format")
-			glog.Info(err)
-			return false, err
+		var c map[string]interface{}
+		if err := json.Unmarshal([]byte(netAttachDef.Spec.Config), &c); err != nil {
+			glog.Infof("configuration string is not in JSON format: %v", err)
+			allErrs = append(allErrs, field.Invalid(configPath, field.OmitValueType{}, "configuration string is not in JSON format: "+jsonErrorDetail(err)))
+			return false, allErrs.ToAggregate()
 		}
 
 		confBytes, err = preprocessCNIConfig(netAttachDef.GetName(), []byte(netAttachDef.Spec.Config))
 		if err != nil {
-			err := errors.New("invalid json")
-			return false, err
+			allErrs = append(allErrs, field.Invalid(configPath, field.OmitValueType{}, "invalid json: "+err.Error()))
+			return false, allErrs.ToAggregate()
 		}
 		if err := validateCNIConfig(confBytes); err != nil {
-			err := errors.New("invalid config")
-			return false, err
+			allErrs = append(allErrs, toFieldErrors(err, configPath)...)
+			return false, allErrs.ToAggregate()
 		}
-		_, err = libcni.ConfListFromBytes(confBytes)
-		if err != nil {
-			glog.Infof("spec is not a valid network config list: %s - trying to parse into standalone config", err)
-			_, err = libcni.ConfFromBytes(confBytes)
-			if err != nil {
-				glog.Infof("spec is not a valid network config: %s", confBytes)
-				err := errors.New("invalid config")
-				return false, err
+		if _, isList := c["plugins"]; isList {
+			if _, err := libcni.ConfListFromBytes(confBytes); err != nil {
+				glog.Infof("spec is not a valid network config list: %s", err)
+				allErrs = append(allErrs, field.Invalid(configPath, field.OmitValueType{}, err.Error()))
 			}
+		} else if _, err := libcni.ConfFromBytes(confBytes); err != nil {
+			glog.Infof("spec is not a valid network config: %s", err)
+			allErrs = append(allErrs, field.Invalid(configPath, field.OmitValueType{}, err.Error()))
 		}
 
 	} else {
 		glog.Infof("Allowing empty spec.config")
 	}
 
+	if len(allErrs) > 0 {
+		return false, allErrs.ToAggregate()
+	}
 	glog.Infof("AdmissionReview request allowed: Network Attachment Definition '%s' is valid", confBytes)
 	return true, nil
 }
@@ -176,9 +284,13 @@ func readAdmissionReview(req *http.Request) (*admissionv1.AdmissionReview, int,
 	var body []byte
 
 	if req.Body != nil {
-		if data, err := ioutil.ReadAll(req.Body); err == nil {
-			body = data
+		data, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			err := errors.Wrap(err, "Error reading HTTP request")
+			glog.Error(err)
+			return nil, readErrorStatus(err), err
 		}
+		body = data
 	}
 
 	if len(body) == 0 {
@@ -202,68 +314,96 @@ func readAdmissionReview(req *http.Request) (*admissionv1.AdmissionReview, int,
 		glog.Error(err)
 		return nil, http.StatusBadRequest, err
 	}
-
-	return ar, http.StatusOK, nil
-}
-
-func deserializeAdmissionReview(body []byte) (*admissionv1.AdmissionReview, error) {
-	ar := &admissionv1.AdmissionReview{}
-	runtimeScheme := runtime.NewScheme()
-	codecs := serializer.NewCodecFactory(runtimeScheme)
-	deserializer := codecs.UniversalDeserializer()
-	_, _, err := deserializer.Decode(body, nil, ar)
-
-	// Decode() won't return an error if the data wasn't actual AdmissionReview
-	if err == nil && ar.TypeMeta.Kind != "AdmissionReview" {
-		err = errors.New("received object is not an AdmissionReview")
+	if ar.Request == nil {
+		err := errors.New("AdmissionReview has no request")
+		glog.Error(err)
+		return nil, http.StatusBadRequest, err
 	}
 
-	return ar, err
+	return ar, http.StatusOK, nil
 }
 
 func analyzeIsolationAnnotation(ar *admissionv1.AdmissionReview) (bool, error) {
-
-	var metadata *metav1.ObjectMeta
 	var pod v1.Pod
 
-	req := ar.Request
-
-	if err := json.Unmarshal(req.Object.Raw, &pod); err != nil {
+	if err := json.Unmarshal(ar.Request.Object.Raw, &pod); err != nil {
 		glog.Errorf("Could not unmarshal raw object: %v", err)
 		return false, err
 	}
 
-	metadata = &pod.ObjectMeta
-	annotations := metadata.GetAnnotations()
+	return validatePodIsolation(pod, requestNamespace(ar, &pod))
+}
+
+// validatePodIsolation checks that the network annotations of the pod only refer to
+// its own namespace, and that the namespace may override the default network
+func validatePodIsolation(pod v1.Pod, namespace string) (bool, error) {
+	var allErrs field.ErrorList
+	annotations := pod.GetAnnotations()
 	if annotations == nil {
 		annotations = map[string]string{}
 	}
 
-	if len(annotations[networksAnnotationKey]) > 0 {
+	if value := annotations[networksAnnotationKey]; len(value) > 0 {
 
-		glog.Infof("Analyzing %s annotation: %s", networksAnnotationKey, annotations[networksAnnotationKey])
+		glog.Infof("Analyzing %s annotation: %s", networksAnnotationKey, value)
 
-		networks, err := parsePodNetworkAnnotation(annotations[networksAnnotationKey], namespaceConstraint)
+		path := annotationsPath.Key(networksAnnotationKey)
+		networks, err := parsePodNetworkAnnotation(value, namespaceConstraint)
 		if err != nil {
 			glog.Errorf("Error during parsePodNetworkAnnotation: %v", err)
-			return false, err
+			allErrs = append(allErrs, field.Invalid(path, value, err.Error()))
+		} else {
+			allErrs = append(allErrs, checkNetworksIsolation(path, networks)...)
 		}
+	}
 
-		for _, item := range networks {
-			fmt.Printf("name: %v", item.Namespace)
-			if item.Namespace != namespaceConstraint {
-				annotationerrorstring := fmt.Sprintf("%s annotations must not refer to namespaced values (must use local namespace, i.e. must not contain a /), rejected: %s (namespace: %s)", networksAnnotationKey, annotations[networksAnnotationKey], item.Namespace)
-				annotationerror := errors.New(annotationerrorstring)
-				return false, annotationerror
-			}
-		}
+	if value := annotations[defaultNetworkAnnotationKey]; len(value) > 0 {
 
-		glog.Infof("Allowed value: %s", annotations[networksAnnotationKey])
+		glog.Infof("Analyzing %s annotation: %s", defaultNetworkAnnotationKey, value)
 
+		path := annotationsPath.Key(defaultNetworkAnnotationKey)
+		networks, err := parsePodNetworkAnnotation(value, namespaceConstraint)
+		if !isDefaultNetworkNamespace(namespace) {
+			allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("annotation is not permitted in namespace %s", namespace)))
+		} else if err != nil {
+			glog.Errorf("Error during parsePodNetworkAnnotation: %v", err)
+			allErrs = append(allErrs, field.Invalid(path, value, err.Error()))
+		} else if len(networks) != 1 {
+			// multus only supports a single default network
+			allErrs = append(allErrs, field.Invalid(path, value, "must refer to exactly one network"))
+		} else {
+			allErrs = append(allErrs, checkNetworksIsolation(path, networks)...)
+		}
 	}
 
+	if len(allErrs) > 0 {
+		return false, allErrs.ToAggregate()
+	}
 	return true, nil
+}
 
+// checkNetworksIsolation verifies that every network refers to the local namespace
+func checkNetworksIsolation(path *field.Path, networks []*types.NetworkSelectionElement) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, item := range networks {
+		if item.Namespace != namespaceConstraint {
+			allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("must not refer to namespaced values (must use local namespace, i.e. must not contain a /), rejected: %s/%s", item.Namespace, item.Name)))
+		}
+	}
+	return allErrs
+}
+
+// isDefaultNetworkNamespace checks whether pods in namespace may override the default network
+func isDefaultNetworkNamespace(namespace string) bool {
+	if len(defaultNetworkNamespaces) == 0 {
+		return true
+	}
+	for _, ns := range defaultNetworkNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
 }
 
 func parsePodNetworkAnnotation(podNetworks, defaultNamespace string) ([]*types.NetworkSelectionElement, error) {
@@ -278,6 +418,11 @@ func parsePodNetworkAnnotation(podNetworks, defaultNamespace string) ([]*types.N
 		if err := json.Unmarshal([]byte(podNetworks), &networks); err != nil {
 			return nil, fmt.Errorf("parsePodNetworkAnnotation: failed to parse pod Network Attachment Selection Annotation JSON format: %v", err)
 		}
+		for i, net := range networks {
+			if net == nil {
+				return nil, fmt.Errorf("parsePodNetworkAnnotation: network selection element %d is null", i)
+			}
+		}
 	} else {
 		// Comma-delimited list of network attachment object names
 		for _, item := range strings.Split(podNetworks, ",") {
@@ -355,6 +500,22 @@ func deserializeNetworkAttachmentDefinition(ar *admissionv1.AdmissionReview) (ne
 	return netAttachDef, err
 }
 
+func deserializePod(ar *admissionv1.AdmissionReview) (v1.Pod, error) {
+	// unmarshal Pod from AdmissionReview request
+	pod := v1.Pod{}
+	err := json.Unmarshal(ar.Request.Object.Raw, &pod)
+	return pod, err
+}
+
+// requestNamespace returns the namespace of the reviewed object,
+// which is not set in the object itself on creation
+func requestNamespace(ar *admissionv1.AdmissionReview, obj metav1.Object) string {
+	if ar.Request.Namespace != "" {
+		return ar.Request.Namespace
+	}
+	return obj.GetNamespace()
+}
+
 func handleValidationError(w http.ResponseWriter, ar *admissionv1.AdmissionReview, orgErr error) {
 	err := prepareAdmissionReviewResponse(false, orgErr.Error(), ar)
 	if err != nil {
@@ -365,35 +526,99 @@ func handleValidationError(w http.ResponseWriter, ar *admissionv1.AdmissionRevie
 	writeResponse(w, ar)
 }
 
+// failureStatus returns the status of a request denied by the failures, with a cause
+// for each of them. Invalid configs are reported as Invalid, other failures as Forbidden.
+func failureStatus(req *admissionv1.AdmissionRequest, failures []CheckFailure) *metav1.Status {
+	status := &metav1.Status{
+		Status: metav1.StatusFailure,
+		Reason: metav1.StatusReasonForbidden,
+		Code:   http.StatusForbidden,
+		Details: &metav1.StatusDetails{
+			Name:  req.Name,
+			Group: req.Kind.Group,
+			Kind:  req.Kind.Kind,
+		},
+	}
+	messages := make([]string, 0, len(failures))
+	for _, failure := range failures {
+		if failure.Check == CheckConfig {
+			status.Reason = metav1.StatusReasonInvalid
+			status.Code = http.StatusUnprocessableEntity
+		}
+		messages = append(messages, failure.Message())
+		status.Details.Causes = append(status.Details.Causes, metav1.StatusCause{
+			Type:    metav1.CauseType(failure.Cause.Type),
+			Message: failure.Cause.ErrorBody(),
+			Field:   failure.Cause.Field,
+		})
+	}
+	if len(messages) == 1 {
+		status.Message = messages[0]
+	} else {
+		status.Message = "[" + strings.Join(messages, ", ") + "]"
+	}
+	return status
+}
+
+// denyRequest writes a response denying the request because of the failures
+func denyRequest(w http.ResponseWriter, ar *admissionv1.AdmissionReview, failures []CheckFailure, warnings []string) {
+	err := prepareAdmissionReviewResponse(false, "", ar)
+	if err != nil {
+		err := errors.Wrap(err, "error preparing AdmissionResponse")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ar.Response.Result = failureStatus(ar.Request, failures)
+	ar.Response.Warnings = warnings
+	writeResponse(w, ar)
+}
+
 func writeResponse(w http.ResponseWriter, ar *admissionv1.AdmissionReview) {
 	// glog.Infof("sending response to the Kubernetes API server")
-	resp, _ := json.Marshal(ar)
+	resp, _ := json.Marshal(reviewForVersion(ar))
 	w.Write(resp)
 }
 
 // IsolateHandler Handles namespace isolation validation.
 func IsolateHandler(w http.ResponseWriter, req *http.Request) {
-
-	var allowed bool
-
 	ar, httpStatus, err := readAdmissionReview(req)
 	if err != nil {
 		http.Error(w, err.Error(), httpStatus)
 		return
 	}
 
-	allowed, err = analyzeIsolationAnnotation(ar)
+	pod, err := deserializePod(ar)
 	if err != nil {
 		handleValidationError(w, ar, err)
 		return
 	}
+	namespace := requestNamespace(ar, &pod)
+
+	// the pods attached to a limited net-attach-def are admitted one at a
+	// time, and counted as attached once admitted
+	limited := limitedNetworks(pod, namespace)
+	if len(limited) > 0 {
+		defer lockAttachments(limited)()
+	}
+
+	// every check is subject to the enforcement mode of the namespace,
+	// failures that are not enforced are collected as warnings
+	failures, warnings := podChecks(pod, namespace)
+	if enforced := enforceFailures(namespace, failures, &warnings); len(enforced) > 0 {
+		denyRequest(w, ar, enforced, warnings)
+		return
+	}
+	if len(limited) > 0 && ar.Request.Operation == admissionv1.Create && (ar.Request.DryRun == nil || !*ar.Request.DryRun) {
+		reserveAttachments(namespace+"/"+pod.GetName(), limited)
+	}
 
-	err = prepareAdmissionReviewResponse(allowed, "", ar)
+	err = prepareAdmissionReviewResponse(true, "", ar)
 	if err != nil {
 		glog.Error(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	ar.Response.Warnings = warnings
 	writeResponse(w, ar)
 }
 
@@ -411,21 +636,24 @@ func ValidateHandler(w http.ResponseWriter, req *http.Request) {
 		handleValidationError(w, ar, err)
 		return
 	}
+	namespace := requestNamespace(ar, &netAttachDef)
 
-	// perform actual object validation
-	allowed, err := validateNetworkAttachmentDefinition(netAttachDef)
-	if err != nil {
-		handleValidationError(w, ar, err)
+	// perform actual object validation, then apply the enforcement mode of
+	// the namespace to the failed checks
+	failures, warnings := netAttachDefChecks(netAttachDef, namespace, ar.Request.Operation == admissionv1.Create)
+	if enforced := enforceFailures(namespace, failures, &warnings); len(enforced) > 0 {
+		denyRequest(w, ar, enforced, warnings)
 		return
 	}
 
 	// perpare response and send it back to the API server
-	err = prepareAdmissionReviewResponse(allowed, "", ar)
+	err = prepareAdmissionReviewResponse(true, "", ar)
 	if err != nil {
 		glog.Error(err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	ar.Response.Warnings = warnings
 	writeResponse(w, ar)
 }
 
@@ -441,4 +669,14 @@ func SetupInClusterClient() {
 	if err != nil {
 		glog.Fatal(err)
 	}
+
+	nadClientset, err = netattachdefClientset.NewForConfig(config)
+	if err != nil {
+		glog.Fatal(err)
+	}
+
+	dynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		glog.Fatal(err)
+	}
 }
@@ -20,12 +20,16 @@ import (
 	. "github.com/onsi/gomega"
 
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 
 	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	netv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 )
@@ -125,6 +129,18 @@ var _ = Describe("Webhook", func() {
 				Expect(resp.StatusCode).To(Equal(http.StatusBadRequest))
 			})
 		})
+
+		Context("AdmissionReview without a request", func() {
+			It("validate and isolate - should return an error", func() {
+				for _, handler := range []http.HandlerFunc{ValidateHandler, IsolateHandler} {
+					req := httptest.NewRequest("POST", "https://fakewebhook/validate", bytes.NewBufferString(`{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview"}`))
+					req.Header.Set("Content-Type", "application/json")
+					w := httptest.NewRecorder()
+					handler(w, req)
+					Expect(w.Result().StatusCode).To(Equal(http.StatusBadRequest))
+				}
+			})
+		})
 	})
 
 	DescribeTable("Network Attachment Definition validation",
@@ -206,4 +222,140 @@ var _ = Describe("Webhook", func() {
 			true, false,
 		),
 	)
+
+	Describe("Pod network isolation", func() {
+		newPodAdmissionReview := func(namespace string, annotations map[string]string) *admissionv1.AdmissionReview {
+			pod := v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "some-pod",
+					Annotations: annotations,
+				},
+			}
+			raw, err := json.Marshal(pod)
+			Expect(err).NotTo(HaveOccurred())
+			return &admissionv1.AdmissionReview{
+				Request: &admissionv1.AdmissionRequest{
+					Namespace: namespace,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+		}
+
+		AfterEach(func() {
+			SetDefaultNetworkNamespaces(nil)
+		})
+
+		DescribeTable("annotations",
+			func(annotations map[string]string, out bool) {
+				allowed, err := analyzeIsolationAnnotation(newPodAdmissionReview("tenant", annotations))
+				Expect(allowed).To(Equal(out))
+				if !out {
+					Expect(err).To(HaveOccurred())
+				}
+			},
+			Entry("no annotations", nil, true),
+			Entry("local networks", map[string]string{networksAnnotationKey: "net1,net2@eth2"}, true),
+			Entry("namespaced networks", map[string]string{networksAnnotationKey: "other/net1"}, false),
+			Entry("local default network", map[string]string{defaultNetworkAnnotationKey: "net1"}, true),
+			Entry("local default network in JSON format", map[string]string{defaultNetworkAnnotationKey: `[{"name": "net1"}]`}, true),
+			Entry("namespaced default network", map[string]string{defaultNetworkAnnotationKey: "other/net1"}, false),
+			Entry("namespaced default network in JSON format", map[string]string{defaultNetworkAnnotationKey: `[{"name": "net1", "namespace": "other"}]`}, false),
+			Entry("multiple default networks", map[string]string{defaultNetworkAnnotationKey: "net1,net2"}, false),
+			Entry("malformed default network", map[string]string{defaultNetworkAnnotationKey: "a/b/c"}, false),
+		)
+
+		Context("when the default network is restricted to some namespaces", func() {
+			BeforeEach(func() {
+				SetDefaultNetworkNamespaces([]string{"infra"})
+			})
+
+			It("should allow the default network annotation in a listed namespace", func() {
+				allowed, err := analyzeIsolationAnnotation(newPodAdmissionReview("infra", map[string]string{defaultNetworkAnnotationKey: "net1"}))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(allowed).To(BeTrue())
+			})
+
+			It("should deny the default network annotation in other namespaces", func() {
+				allowed, err := analyzeIsolationAnnotation(newPodAdmissionReview("tenant", map[string]string{defaultNetworkAnnotationKey: "net1"}))
+				Expect(err).To(MatchError(ContainSubstring("not permitted in namespace tenant")))
+				Expect(allowed).To(BeFalse())
+			})
+
+			It("should not affect the networks annotation", func() {
+				allowed, err := analyzeIsolationAnnotation(newPodAdmissionReview("tenant", map[string]string{networksAnnotationKey: "net1"}))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(allowed).To(BeTrue())
+			})
+		})
+	})
+})
+
+var _ = Describe("Denial causes", func() {
+	newNetAttachDef := func(name, config string) netv1.NetworkAttachmentDefinition {
+		return netv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "tenant"},
+			Spec:       netv1.NetworkAttachmentDefinitionSpec{Config: config},
+		}
+	}
+
+	DescribeTable("config validation errors",
+		func(config string, messages ...string) {
+			_, err := validateNetworkAttachmentDefinition(newNetAttachDef("some-valid-name", config))
+			Expect(err).To(HaveOccurred())
+			for _, message := range messages {
+				Expect(err.Error()).To(ContainSubstring(message))
+			}
+		},
+		Entry("invalid JSON", `{"type": "bridge" "name": "x"}`,
+			"spec.config: Invalid value: configuration string is not in JSON format", "(at offset"),
+		Entry("plugins is not a list", `{"cniVersion": "0.3.1", "name": "net", "plugins": 5}`,
+			"spec.config.plugins: Invalid value: 5: must be a list"),
+		Entry("invalid ipam subnet", `{"cniVersion": "0.3.1", "name": "net", "plugins": [
+				{"type": "bridge"},
+				{"type": "tuning", "ipam": {"type": "host-local", "subnet": "10.1.0.0/33"}}]}`,
+			`spec.config.plugins[1].ipam.subnet: Invalid value: "10.1.0.0/33": invalid CIDR address: 10.1.0.0/33`),
+		Entry("empty plugin list", `{"cniVersion": "0.3.1", "name": "net", "plugins": []}`,
+			"spec.config: Invalid value: ", "no plugins in list"),
+	)
+
+	It("should return every error at once", func() {
+		_, err := validateNetworkAttachmentDefinition(newNetAttachDef("some?invalid?name", `{"cniVersion": "0.3.1"}`))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("metadata.name: Invalid value"))
+		Expect(err.Error()).To(ContainSubstring("spec.config.type: Required value: missing 'type' in cni config"))
+	})
+
+	Describe("responses", func() {
+		AfterEach(func() {
+			SetQuotas(Quotas{})
+		})
+
+		It("should deny an invalid config as Invalid with a cause per error", func() {
+			nad := newNetAttachDef("some?invalid?name", `{"cniVersion": "0.3.1"}`)
+			resp := postAdmissionReview(ValidateHandler, "tenant", admissionv1.Create, nad)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Reason).To(Equal(metav1.StatusReasonInvalid))
+			Expect(resp.Result.Code).To(Equal(int32(http.StatusUnprocessableEntity)))
+			Expect(resp.Result.Details.Causes).To(ContainElement(metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueRequired,
+				Message: "Required value: missing 'type' in cni config",
+				Field:   "spec.config.type",
+			}))
+			Expect(resp.Result.Details.Causes).To(ContainElement(HaveField("Field", "metadata.name")))
+		})
+
+		It("should deny a policy violation as Forbidden", func() {
+			SetQuotas(Quotas{MaxNetworksPerPod: 1})
+			pod := newPod(map[string]string{networksAnnotationKey: "net1,net2"})
+			resp := postAdmissionReview(IsolateHandler, "tenant", admissionv1.Create, pod)
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Reason).To(Equal(metav1.StatusReasonForbidden))
+			Expect(resp.Result.Code).To(Equal(int32(http.StatusForbidden)))
+			Expect(resp.Result.Details.Causes).To(Equal([]metav1.StatusCause{{
+				Type:    metav1.CauseType(field.ErrorTypeTooMany),
+				Message: "Too many: 2: pod requests 2 networks, the limit in namespace tenant is 1",
+				Field:   "metadata.annotations[k8s.v1.cni.cncf.io/networks]",
+			}}))
+		})
+	})
 })